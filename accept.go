@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// 25. 다른 노드에게 받은 블록을 검증하여 블록체인에 추가
+// 직접 채굴하는 AddBlock() 과 달리 이미 채굴된 블록을 받으므로 작업증명, 높이, 이전 블록, 트랜잭션을 모두 검증한 뒤 connectBlock() 으로 저장
+//
+// 26. 다른 갈래(side branch)의 블록을 저장하고 누적 작업량이 가장 많은 갈래를 메인 체인으로 재구성
+var (
+	errBlockExists = errors.New("block already exists")
+	errOrphanBlock = errors.New("previous block not found")
+)
+
+// 블록을 검증하여 블록체인에 추가하기 위한 메서드
+//   - 이미 가진 블록이면 errBlockExists, 이전 블록을 모르면 errOrphanBlock 을 반환
+//
+// 26) 다른 갈래의 블록과 재구성으로 인한 변경점
+//   - 마지막 블록의 다음 블록이 아니라면 작업증명만 검증하여 다른 갈래의 블록으로 저장
+//   - 다른 갈래의 누적 작업량이 메인 체인보다 많아지면 그 갈래로 재구성하며, 메인 체인에서 해제된 블록들을 반환
+//
+// 27) rollback 으로 해제되어 저장만 되어 있는 블록을 다시 받으면, 메인 체인보다 작업량이 많을 때 그 블록까지 재구성
+// 28) 작업증명을 검증한 뒤 저장하기 전에 머클 트리가 변형된 블록(CheckMerkleMutation)을 거부
+// 31) 검증부터 저장까지 쓰기 잠금(writeMu)을 잡고 실행
+// 36) 제네시스 블록이 아니라면 블록의 시간을 검증(ErrBlockTimeTooOld, ErrBlockTimeTooNew), 다른 갈래의 블록도 같음
+func (bc *Blockchain) AcceptBlock(block *Block) ([]*Block, error) {
+	bc.writeMu.Lock()
+	defer bc.writeMu.Unlock()
+
+	if _, err := bc.GetBlock(block.Hash); err == nil {
+		index := bc.GetBlockIndex(block.Hash)
+		if index == nil || index.Status == blockStatusInvalid || bc.IsMainChain(block.Hash, block.Height) ||
+			index.Work().Cmp(bc.GetBlockIndex(bc.l).Work()) <= 0 {
+			return nil, errBlockExists
+		}
+		return bc.reorganize(block)
+	}
+
+	height := 0
+	if len(block.PrevBlockHash) > 0 {
+		prev := bc.GetBlockIndex(block.PrevBlockHash)
+		if prev == nil {
+			return nil, errOrphanBlock
+		}
+		if prev.Status == blockStatusInvalid {
+			return nil, fmt.Errorf("block %x builds on invalid block %x", block.Hash, block.PrevBlockHash)
+		}
+		height = prev.Height + 1
+	} else if len(bc.l) > 0 || !bytes.Equal(block.Hash, GenesisBlock().Hash) {
+		return nil, fmt.Errorf("block %x is a different genesis block", block.Hash)
+	}
+	if block.Height != height {
+		return nil, fmt.Errorf("block %x has height %d, expected %d", block.Hash, block.Height, height)
+	}
+	if err := CheckProofOfWork(block); err != nil {
+		return nil, err
+	}
+	if err := CheckMerkleMutation(block); err != nil {
+		return nil, err
+	}
+	if height > 0 {
+		mtp, err := bc.MedianTimePast(block.PrevBlockHash)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkBlockTime(block.Hash, block.Timestamp, mtp); err != nil {
+			return nil, err
+		}
+	}
+
+	if bytes.Equal(block.PrevBlockHash, bc.l) {
+		if err := bc.ValidateBlockTransactions(block); err != nil {
+			return nil, err
+		}
+		return nil, bc.connectBlock(block)
+	}
+
+	if err := bc.storeSideBlock(block); err != nil {
+		return nil, err
+	}
+	if bc.GetBlockIndex(block.Hash).Work().Cmp(bc.GetBlockIndex(bc.l).Work()) <= 0 {
+		return nil, nil
+	}
+
+	return bc.reorganize(block)
+}
+
+// 메인 체인이 아닌 블록을 저장하기 위한 메서드
+// 트랜잭션은 재구성으로 메인 체인에 연결될 때 검증
+func (bc *Blockchain) storeSideBlock(block *Block) error {
+	return bc.db.Update(func(tx StorageTx) error {
+		if err := tx.Bucket([]byte(BlocksBucket)).Put(block.Hash, block.Serialize()); err != nil {
+			return err
+		}
+
+		return putBlockIndex(tx, block, blockStatusHeader)
+	})
+}
+
+// 메인 체인을 newTip 으로 끝나는 갈래로 재구성하기 위한 메서드
+//  1. newTip 에서 이전 블록을 따라가며 메인 체인과 갈라진 지점(fork)을 찾음
+//  2. 메인 체인의 마지막 블록부터 갈라진 지점까지 블록을 해제
+//  3. 갈래의 블록들을 검증하며 순서대로 연결
+//
+// 갈래의 블록이 검증에 실패하면 그 블록을 invalid 로 표시하고 원래의 메인 체인으로 되돌림
+// 27) 갈래에 invalid 로 표시된 블록이 있다면 재구성하지 않음
+func (bc *Blockchain) reorganize(newTip *Block) ([]*Block, error) {
+	var branch []*Block
+	for b := newTip; !bc.IsMainChain(b.Hash, b.Height); {
+		if index := bc.GetBlockIndex(b.Hash); index != nil && index.Status == blockStatusInvalid {
+			return nil, fmt.Errorf("block %x builds on invalid block %x", newTip.Hash, b.Hash)
+		}
+		branch = append([]*Block{b}, branch...)
+
+		prev, err := bc.GetBlock(b.PrevBlockHash)
+		if err != nil {
+			return nil, err
+		}
+		b = prev
+	}
+	fork := branch[0].Height - 1
+
+	disconnected, err := bc.rollbackTo(fork)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, block := range branch {
+		err := bc.ValidateBlockTransactions(block)
+		if err == nil {
+			err = bc.connectBlock(block)
+		}
+		if err == nil {
+			continue
+		}
+
+		for _, invalid := range branch[i:] {
+			if statusErr := bc.setBlockStatus(invalid, blockStatusInvalid); statusErr != nil {
+				return nil, statusErr
+			}
+		}
+		for j := i - 1; j >= 0; j-- {
+			if rollbackErr := bc.disconnectBlock(branch[j]); rollbackErr != nil {
+				return nil, rollbackErr
+			}
+		}
+		for j := len(disconnected) - 1; j >= 0; j-- {
+			if rollbackErr := bc.connectBlock(disconnected[j]); rollbackErr != nil {
+				return nil, rollbackErr
+			}
+		}
+
+		return nil, fmt.Errorf("reorganization to %x failed at block %x: %v", newTip.Hash, block.Hash, err)
+	}
+
+	return disconnected, nil
+}
+
+// 블록 해시가 작업증명 데이터의 해시와 같고 난이도(target)를 만족하는지 검사하기 위한 함수
+// 28) 블록 헤더로 검사
+func CheckProofOfWork(block *Block) error {
+	header := block.Header()
+
+	return CheckHeaderProofOfWork(&header)
+}
+
+// 블록의 트랜잭션들을 검증하기 위한 메서드
+//   - 트랜잭션 ID 가 내용의 해시와 같아야 함
+//   - 코인베이스가 아닌 트랜잭션은 ValidateTransaction() 을 통과해야 하며, 블록 안에서 같은 출력을 두 번 사용할 수 없음
+//   - 코인베이스는 ValidateCoinbase() 로 검증
+func (bc *Blockchain) ValidateBlockTransactions(block *Block) error {
+	if len(block.Transactions) == 0 {
+		return fmt.Errorf("block %x has no transactions", block.Hash)
+	}
+
+	spent := make(map[string]bool)
+	for _, tx := range block.Transactions {
+		if !bytes.Equal(tx.ID, tx.Hash()) {
+			return fmt.Errorf("transaction %x has an invalid id", tx.ID)
+		}
+		if tx.IsCoinbase() {
+			continue
+		}
+		if err := bc.ValidateTransaction(tx); err != nil {
+			return err
+		}
+		for _, in := range tx.Vin {
+			outpoint := Outpoint{in.Txid, in.Vout}.String()
+			if spent[outpoint] {
+				return fmt.Errorf("output %s is spent twice in block %x", outpoint, block.Hash)
+			}
+			spent[outpoint] = true
+		}
+	}
+
+	return bc.ValidateCoinbase(block.Transactions, block.Height)
+}
+
+// 다른 노드에게 어디까지 블록을 가지고 있는지 알리기 위한 블록 해시 목록(locator)
+// 마지막 블록부터 10개는 하나씩, 그 이후로는 간격을 두 배씩 늘리며 제네시스 블록까지 포함
+// 28) 경량 클라이언트의 블록 헤더에도 사용하기 위해 blockLocator() 로 분리
+func (bc *Blockchain) BlockLocator() [][]byte {
+	return blockLocator(bc.GetBestHeight(), bc.GetBlockHash)
+}
+
+// best 높이부터의 locator, hashAt 은 높이에 해당하는 블록 해시
+func blockLocator(best int, hashAt func(height int) ([]byte, error)) [][]byte {
+	var locator [][]byte
+
+	step := 1
+	for height := best; height >= 0; height -= step {
+		hash, err := hashAt(height)
+		if err != nil {
+			break
+		}
+		locator = append(locator, hash)
+
+		if height == 0 {
+			return locator
+		}
+		if len(locator) >= 10 {
+			step *= 2
+		}
+		if height-step < 0 {
+			step = height
+		}
+	}
+
+	return locator
+}
+
+// locator 의 해시 중 이 블록체인에 있는 가장 높은 블록의 높이를 구하기 위한 메서드, 없다면 -1
+func (bc *Blockchain) FindLocatorHeight(locator [][]byte) int {
+	for _, hash := range locator {
+		block, err := bc.GetBlock(hash)
+		if err != nil {
+			continue
+		}
+		if mainHash, err := bc.GetBlockHash(block.Height); err == nil && bytes.Equal(mainHash, hash) {
+			return block.Height
+		}
+	}
+
+	return -1
+}
+
+// height 다음 블록부터 최대 max 개의 블록 해시를 구하기 위한 메서드
+func (bc *Blockchain) BlockHashesAfter(height, max int) [][]byte {
+	var hashes [][]byte
+
+	best := bc.GetBestHeight()
+	for h := height + 1; h <= best && len(hashes) < max; h++ {
+		hash, err := bc.GetBlockHash(h)
+		if err != nil {
+			break
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+// 18. 주소별 거래 내역
+// getbalance 는 UTXO 의 합만 보여주기 때문에 주소로 들어오고 나간 거래를 볼 수 없었음
+// 블록을 추가할 때 블록의 트랜잭션이 사용한 주소(출력의 공개키 해시, 입력의 공개키)를 addrindex 버킷에 색인하고
+// 거래 내역은 체인 전체를 순회하지 않고 색인에 있는 블록만 읽어서 구성
+//
+// 19. 주소 색인 확장 및 선택 사항으로 변경
+// FindUnspentTransactions(), GetBalance() 는 매번 모든 블록을 순회하며 입력의 공개키를 UsesKey() 로 해싱하였음
+// 주소가 받은 출력과 그 출력을 소비한 입력을 addrouts 버킷에 색인하여 UTXO 를 색인에서 바로 찾도록 함
+//   - 색인은 선택 사항이며 new -addrindex 로 켜거나, reindexaddr 로 기존 블록체인에 대해 만들 수 있음
+//   - 두 버킷이 존재하면 색인이 켜진 것으로 보고 AddBlock() 에서 갱신
+const (
+	AddrIndexBucket = "addrindex"
+	AddrOutsBucket  = "addrouts"
+)
+
+var errAddrIndexDisabled = errors.New("address index is disabled, run 'reindexaddr' to build it")
+
+// 색인의 키 : 공개키 해시(20) + 블록 높이(4) + 트랜잭션 위치(4)
+// 빅엔디안으로 저장하여 같은 주소의 항목들이 블록 높이 순서로 정렬되도록 함
+func addrIndexKey(pubKeyHash []byte, height, txIndex int) []byte {
+	key := make([]byte, len(pubKeyHash)+8)
+	copy(key, pubKeyHash)
+	binary.BigEndian.PutUint32(key[len(pubKeyHash):], uint32(height))
+	binary.BigEndian.PutUint32(key[len(pubKeyHash)+4:], uint32(txIndex))
+
+	return key
+}
+
+// 출력 색인의 키 : 공개키 해시(20) + 트랜잭션 ID(32) + 출력 인덱스(4)
+func addrOutKey(pubKeyHash, txid []byte, vout int) []byte {
+	key := make([]byte, len(pubKeyHash)+len(txid)+4)
+	copy(key, pubKeyHash)
+	copy(key[len(pubKeyHash):], txid)
+	binary.BigEndian.PutUint32(key[len(pubKeyHash)+len(txid):], uint32(vout))
+
+	return key
+}
+
+// 주소 색인이 켜져 있는지 확인하기 위한 함수
+func addrIndexEnabled(tx StorageTx) bool {
+	return tx.Bucket([]byte(AddrIndexBucket)) != nil && tx.Bucket([]byte(AddrOutsBucket)) != nil
+}
+
+// 주소 색인을 위한 버킷을 만들기 위한 함수
+func createAddrIndex(tx StorageTx) error {
+	if _, err := tx.CreateBucketIfNotExists([]byte(AddrIndexBucket)); err != nil {
+		return err
+	}
+	_, err := tx.CreateBucketIfNotExists([]byte(AddrOutsBucket))
+
+	return err
+}
+
+// 블록의 트랜잭션들을 트랜잭션이 사용한 주소별로 색인하기 위한 함수
+// AddBlock(), CreateBlockchain() 에서 블록을 저장하는 같은 bolt 트랜잭션 안에서 호출
+// 19) 주소가 받은 출력을 addrouts 에 추가하고, 입력이 소비한 출력에 소비한 입력을 기록
+func indexBlockAddresses(tx StorageTx, block *Block, height int) error {
+	b := tx.Bucket([]byte(AddrIndexBucket))
+	outs := tx.Bucket([]byte(AddrOutsBucket))
+
+	for txIndex, t := range block.Transactions {
+		entry, err := json.Marshal(AddrIndexEntry{block.Hash, txIndex})
+		if err != nil {
+			return err
+		}
+
+		for _, pubKeyHash := range t.pubKeyHashes() {
+			if err := b.Put(addrIndexKey(pubKeyHash, height, txIndex), entry); err != nil {
+				return err
+			}
+		}
+
+		if !t.IsCoinbase() {
+			for vin, in := range t.Vin {
+				key := addrOutKey(HashPubKey(in.PubKey), in.Txid, in.Vout)
+
+				var out AddrOutput
+				encoded := outs.Get(key)
+				if encoded == nil {
+					return fmt.Errorf("spent output %x:%d is not indexed", in.Txid, in.Vout)
+				}
+				if err := json.Unmarshal(encoded, &out); err != nil {
+					return err
+				}
+				out.SpentTxid, out.SpentVin = t.ID, vin
+
+				if err := putAddrOutput(outs, key, out); err != nil {
+					return err
+				}
+			}
+		}
+
+		for vout, out := range t.Vout {
+			key := addrOutKey(out.PubKeyHash, t.ID, vout)
+			if err := putAddrOutput(outs, key, AddrOutput{out.Value, height, block.Hash, nil, 0}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// 26. 블록을 메인 체인에서 해제할 때 indexBlockAddresses() 가 추가한 색인을 되돌리기 위한 함수
+// 트랜잭션을 역순으로 처리하며 블록의 색인 항목과 새 출력을 지우고, 입력이 소비한 출력을 다시 소비되지 않은 상태로 바꿈
+func unindexBlockAddresses(tx StorageTx, block *Block) error {
+	b := tx.Bucket([]byte(AddrIndexBucket))
+	outs := tx.Bucket([]byte(AddrOutsBucket))
+
+	for txIndex := len(block.Transactions) - 1; txIndex >= 0; txIndex-- {
+		t := block.Transactions[txIndex]
+
+		for _, pubKeyHash := range t.pubKeyHashes() {
+			if err := b.Delete(addrIndexKey(pubKeyHash, block.Height, txIndex)); err != nil {
+				return err
+			}
+		}
+
+		for vout, out := range t.Vout {
+			if err := outs.Delete(addrOutKey(out.PubKeyHash, t.ID, vout)); err != nil {
+				return err
+			}
+		}
+
+		if !t.IsCoinbase() {
+			for _, in := range t.Vin {
+				key := addrOutKey(HashPubKey(in.PubKey), in.Txid, in.Vout)
+
+				var out AddrOutput
+				encoded := outs.Get(key)
+				if encoded == nil {
+					return fmt.Errorf("spent output %x:%d is not indexed", in.Txid, in.Vout)
+				}
+				if err := json.Unmarshal(encoded, &out); err != nil {
+					return err
+				}
+				out.SpentTxid, out.SpentVin = nil, 0
+
+				if err := putAddrOutput(outs, key, out); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func putAddrOutput(b StorageBucket, key []byte, out AddrOutput) error {
+	encoded, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(key, encoded)
+}
+
+// 주소 색인을 처음부터 다시 만들기 위한 메서드
+// 기존 색인을 지우고 제네시스 블록부터 마지막 블록까지 순서대로 색인하며, 이후로는 AddBlock() 에서 갱신됨
+// 하나의 bolt 트랜잭션 안에서 높이 색인(heights)으로 순회하므로 NewBlockchainForwardIterator() 대신 직접 조회
+func (bc *Blockchain) ReindexAddresses() error {
+	bc.writeMu.Lock()
+	defer bc.writeMu.Unlock()
+
+	err := bc.db.Update(func(tx StorageTx) error {
+		for _, name := range []string{AddrIndexBucket, AddrOutsBucket} {
+			if tx.Bucket([]byte(name)) != nil {
+				if err := tx.DeleteBucket([]byte(name)); err != nil {
+					return err
+				}
+			}
+		}
+		if err := createAddrIndex(tx); err != nil {
+			return err
+		}
+
+		tip := DeserializeBlock(tx.Bucket([]byte(BlocksBucket)).Get(bc.l))
+		for height := 0; height <= tip.Height; height++ {
+			block, err := getBlockByHeightTx(tx, height)
+			if err != nil {
+				return err
+			}
+			if err := indexBlockAddresses(tx, block, height); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	bc.mu.Lock()
+	bc.addrIndex = true
+	bc.mu.Unlock()
+
+	return nil
+}
+
+// 색인에서 공개키 해시로 잠긴 UTXO 를 찾기 위한 메서드
+// 색인이 꺼져 있다면 errAddrIndexDisabled 를 반환
+func (bc *Blockchain) findIndexedUTXO(pubKeyHash []byte) ([]UTXO, []*AddrOutput, error) {
+	if !bc.hasAddrIndex() {
+		return nil, nil, errAddrIndexDisabled
+	}
+
+	var UTXOs []UTXO
+	var outputs []*AddrOutput
+
+	err := bc.db.View(func(tx StorageTx) error {
+		return tx.Bucket([]byte(AddrOutsBucket)).ForEachPrefix(pubKeyHash, func(k, v []byte) error {
+			var out AddrOutput
+			if err := json.Unmarshal(v, &out); err != nil {
+				return err
+			}
+			if out.SpentTxid != nil {
+				return nil
+			}
+
+			txid := append([]byte{}, k[len(pubKeyHash):len(k)-4]...)
+			vout := int(binary.BigEndian.Uint32(k[len(k)-4:]))
+			UTXOs = append(UTXOs, UTXO{Outpoint{txid, vout}, TXOutput{out.Value, pubKeyHash}})
+			outputs = append(outputs, &out)
+
+			return nil
+		})
+	})
+
+	return UTXOs, outputs, err
+}
+
+// 트랜잭션이 사용한 공개키 해시들(출력을 받은 주소와 입력을 서명한 주소)을 중복없이 구하기 위한 메서드
+func (tx *Transaction) pubKeyHashes() [][]byte {
+	var pubKeyHashes [][]byte
+	seen := make(map[string]bool)
+
+	add := func(pubKeyHash []byte) {
+		if !seen[string(pubKeyHash)] {
+			seen[string(pubKeyHash)] = true
+			pubKeyHashes = append(pubKeyHashes, pubKeyHash)
+		}
+	}
+
+	if !tx.IsCoinbase() {
+		for _, in := range tx.Vin {
+			add(HashPubKey(in.PubKey))
+		}
+	}
+	for _, out := range tx.Vout {
+		add(out.PubKeyHash)
+	}
+
+	return pubKeyHashes
+}
+
+// 주소의 거래 내역을 구하기 위한 메서드
+// 색인을 오래된 거래부터 읽어 잔액을 계산한 뒤, 최신 거래부터 skip 개를 건너뛰고 count 개를 반환
+// 두 번째 반환값은 전체 거래 수
+func (bc *Blockchain) GetAddressHistory(address string, skip, count int) ([]HistoryEntry, int, error) {
+	if !ValidateAddress(address) {
+		return nil, 0, fmt.Errorf("invalid address '%s'", address)
+	}
+	pubKeyHash, _, _ := base58.CheckDecode(address)
+
+	if !bc.hasAddrIndex() {
+		return nil, 0, errAddrIndexDisabled
+	}
+
+	var history []HistoryEntry
+	received := make(map[string]uint64) // 이 주소가 받은 출력(txid:vout) -> 금액
+	var balance uint64
+
+	err := bc.db.View(func(tx StorageTx) error {
+		b := tx.Bucket([]byte(AddrIndexBucket))
+		blocks := tx.Bucket([]byte(BlocksBucket))
+
+		return b.ForEachPrefix(pubKeyHash, func(k, v []byte) error {
+			if len(k) != len(pubKeyHash)+8 {
+				return nil
+			}
+
+			var entry AddrIndexEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+
+			encodedBlock := blocks.Get(entry.BlockHash)
+			if encodedBlock == nil {
+				return fmt.Errorf("indexed block %x not found", entry.BlockHash)
+			}
+			block := DeserializeBlock(encodedBlock)
+			t := block.Transactions[entry.TxIndex]
+
+			h := HistoryEntry{
+				Height:    int(binary.BigEndian.Uint32(k[len(pubKeyHash):])),
+				Timestamp: block.Timestamp,
+				Txid:      t.ID,
+			}
+
+			var in, out, toOthers uint64
+			var others []string
+			if !t.IsCoinbase() {
+				for _, vin := range t.Vin {
+					if vin.UsesKey(pubKeyHash) {
+						in += received[Outpoint{vin.Txid, vin.Vout}.String()]
+					}
+				}
+			}
+			for outIdx, vout := range t.Vout {
+				if bytes.Compare(vout.PubKeyHash, pubKeyHash) == 0 {
+					out += vout.Value
+					received[Outpoint{t.ID, outIdx}.String()] = vout.Value
+				} else {
+					toOthers += vout.Value
+					others = appendUnique(others, base58.CheckEncode(vout.PubKeyHash, netParams.AddressVersion))
+				}
+			}
+
+			switch {
+			case in == 0:
+				h.Direction, h.Amount = "received", out
+				h.Counterparties = t.senders()
+			case toOthers == 0:
+				h.Direction = "self"
+			default:
+				h.Direction, h.Amount = "sent", toOthers
+				h.Counterparties = others
+			}
+
+			balance = balance + out - in
+			h.Balance = balance
+			history = append(history, h)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := len(history)
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	if skip > len(history) {
+		skip = len(history)
+	}
+	history = history[skip:]
+	if count >= 0 && count < len(history) {
+		history = history[:count]
+	}
+
+	return history, total, nil
+}
+
+// 트랜잭션의 입력에 서명한 주소들, 코인베이스 트랜잭션이면 "coinbase"
+func (tx *Transaction) senders() []string {
+	if tx.IsCoinbase() {
+		return []string{"coinbase"}
+	}
+
+	var senders []string
+	for _, in := range tx.Vin {
+		senders = appendUnique(senders, base58.CheckEncode(HashPubKey(in.PubKey), netParams.AddressVersion))
+	}
+
+	return senders
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, item := range list {
+		if item == s {
+			return list
+		}
+	}
+
+	return append(list, s)
+}
+
+// 블록의 타임스탬프를 출력하기 위한 형식
+func formatTimestamp(timestamp int64) string {
+	return time.Unix(timestamp, 0).Format("2006-01-02 15:04:05")
+}
+
+// 거래 내역을 출력하기 위한 형식
+func (h HistoryEntry) String() string {
+	return fmt.Sprintf("%6d  %s  %s  %-8s %8d  balance %d  %v", h.Height, formatTimestamp(h.Timestamp), hex.EncodeToString(h.Txid), h.Direction, h.Amount, h.Balance, h.Counterparties)
+}
@@ -0,0 +1,32 @@
+package main
+
+// 주소 색인(addrindex)에 저장되는 값
+// 키는 공개키 해시 + 블록 높이 + 블록 안에서의 트랜잭션 위치이며, 값으로 트랜잭션이 담긴 블록의 해시를 가짐
+type AddrIndexEntry struct {
+	BlockHash []byte
+	TxIndex   int
+}
+
+// 주소의 거래 내역 하나
+//   - Direction : received(받음), sent(보냄), self(내 주소로만 보냄)
+//   - Amount    : 받은 경우 받은 금액, 보낸 경우 내 주소를 제외한 곳으로 나간 금액
+//   - Balance   : 이 거래까지 반영된 잔액
+type HistoryEntry struct {
+	Height         int
+	Timestamp      int64
+	Txid           []byte
+	Direction      string
+	Amount         uint64
+	Counterparties []string
+	Balance        uint64
+}
+
+// 주소가 받은 출력(addrouts)의 색인 값
+// 키는 공개키 해시 + 트랜잭션 ID + 출력 인덱스이며, 출력이 소비되면 소비한 입력(SpentTxid, SpentVin)을 기록
+type AddrOutput struct {
+	Value     uint64
+	Height    int
+	BlockHash []byte
+	SpentTxid []byte
+	SpentVin  int
+}
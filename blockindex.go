@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+)
+
+// 26. 블록 색인과 누적 작업량
+// 블록은 항상 마지막 블록(l) 다음에만 추가할 수 있었기 때문에, 다른 노드가 같은 높이의 블록을 먼저 채굴하면 그 블록을 저장할 수 없었음
+// 메인 체인이 아닌 블록도 저장하고 blockindex 버킷에 블록마다 제네시스 블록부터의 누적 작업량을 기록하여
+// 더 많은 작업량을 가진 갈래가 나타나면 그 갈래로 재구성(reorganize)
+const (
+	BlockIndexBucket = "blockindex"
+
+	blockStatusHeader  = "headers"
+	blockStatusValid   = "valid"
+	blockStatusInvalid = "invalid"
+)
+
+// 블록 하나의 작업량, 2^256 / (target + 1)
+// 난이도(target)가 낮을수록 해시를 찾기 어려우므로 작업량이 큼
+// 28) 블록 헤더의 작업량에도 사용하기 위해 targetWork() 로 분리
+func blockWork(block *Block) *big.Int {
+	return targetWork(NewProofOfWork(block).target)
+}
+
+func targetWork(target *big.Int) *big.Int {
+	target = new(big.Int).Add(target, big.NewInt(1))
+
+	return new(big.Int).Div(new(big.Int).Lsh(big.NewInt(1), 256), target)
+}
+
+func (bi *BlockIndex) Work() *big.Int {
+	return new(big.Int).SetBytes(bi.ChainWork)
+}
+
+func getBlockIndexTx(tx StorageTx, hash []byte) *BlockIndex {
+	b := tx.Bucket([]byte(BlockIndexBucket))
+	if b == nil {
+		return nil
+	}
+	encoded := b.Get(hash)
+	if encoded == nil {
+		return nil
+	}
+
+	var index BlockIndex
+	if err := json.Unmarshal(encoded, &index); err != nil {
+		log.Panic(err)
+	}
+
+	return &index
+}
+
+// 블록을 블록 색인에 추가하거나 상태를 바꾸기 위한 함수
+// 누적 작업량은 이전 블록의 누적 작업량 + 이 블록의 작업량
+func putBlockIndex(tx StorageTx, block *Block, status string) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(BlockIndexBucket))
+	if err != nil {
+		return err
+	}
+
+	work := blockWork(block)
+	if len(block.PrevBlockHash) > 0 {
+		prev := getBlockIndexTx(tx, block.PrevBlockHash)
+		if prev == nil {
+			return fmt.Errorf("previous block %x is not indexed", block.PrevBlockHash)
+		}
+		work.Add(work, prev.Work())
+	}
+
+	encoded, err := json.Marshal(BlockIndex{block.Hash, block.PrevBlockHash, block.Height, block.Timestamp, work.Bytes(), status})
+	if err != nil {
+		return err
+	}
+
+	return b.Put(block.Hash, encoded)
+}
+
+// 블록 해시로 블록 색인을 가져오기 위한 메서드, 없다면 nil
+func (bc *Blockchain) GetBlockIndex(hash []byte) *BlockIndex {
+	var index *BlockIndex
+
+	err := bc.db.View(func(tx StorageTx) error {
+		index = getBlockIndexTx(tx, hash)
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return index
+}
+
+// 마지막 블록의 누적 작업량을 가져오기 위한 메서드, 블록이 없다면 0
+func (bc *Blockchain) TipWork() *big.Int {
+	if index := bc.GetBlockIndex(bc.Tip()); index != nil {
+		return index.Work()
+	}
+
+	return new(big.Int)
+}
+
+// 블록의 검증 상태를 바꾸기 위한 메서드
+func (bc *Blockchain) setBlockStatus(block *Block, status string) error {
+	return bc.db.Update(func(tx StorageTx) error {
+		return putBlockIndex(tx, block, status)
+	})
+}
+
+// 블록이 메인 체인에 있는지 확인하기 위한 메서드
+func (bc *Blockchain) IsMainChain(hash []byte, height int) bool {
+	mainHash, err := bc.GetBlockHash(height)
+
+	return err == nil && bytes.Equal(mainHash, hash)
+}
+
+// 블록 색인의 모든 블록을 읽기 위한 메서드, 키는 블록 해시
+func (bc *Blockchain) readBlockIndexes() map[string]*BlockIndex {
+	indexes := make(map[string]*BlockIndex)
+
+	err := bc.db.View(func(tx StorageTx) error {
+		b := tx.Bucket([]byte(BlockIndexBucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var index BlockIndex
+			if err := json.Unmarshal(v, &index); err != nil {
+				return err
+			}
+			indexes[string(k)] = &index
+
+			return nil
+		})
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return indexes
+}
+
+// 갈래의 끝(자식 블록이 없는 블록)들을 구하기 위한 함수
+// invalid 가 아닌 블록의 invalid 인 자식은 세지 않으므로, invalidateblock 으로 자식이 모두 invalid 가 된 블록도 갈래의 끝
+func chainTipIndexes(indexes map[string]*BlockIndex) []*BlockIndex {
+	hasChild := make(map[string]bool)
+	for _, index := range indexes {
+		prev := indexes[string(index.PrevBlockHash)]
+		if index.Status == blockStatusInvalid && prev != nil && prev.Status != blockStatusInvalid {
+			continue
+		}
+		hasChild[string(index.PrevBlockHash)] = true
+	}
+
+	var tips []*BlockIndex
+	for key, index := range indexes {
+		if !hasChild[key] {
+			tips = append(tips, index)
+		}
+	}
+
+	return tips
+}
+
+// 블록체인의 모든 갈래의 끝(자식 블록이 없는 블록)을 구하기 위한 메서드
+// 메인 체인의 마지막 블록은 active, 다른 갈래는 메인 체인에서 갈라진 지점부터의 길이(BranchLen)와 검증 상태를 가짐
+// 27) 자식이 모두 invalid 인 블록도 갈래의 끝(chainTipIndexes)
+func (bc *Blockchain) GetChainTips() []ChainTip {
+	best := bc.Tip()
+	indexes := bc.readBlockIndexes()
+
+	tips := []ChainTip{}
+	for _, index := range chainTipIndexes(indexes) {
+
+		tip := ChainTip{index.Height, hex.EncodeToString(index.Hash), 0, "active"}
+		if !bytes.Equal(index.Hash, best) {
+			status := blockStatusValid
+			for i := index; i != nil && !bc.IsMainChain(i.Hash, i.Height); i = indexes[string(i.PrevBlockHash)] {
+				tip.BranchLen++
+				if i.Status == blockStatusInvalid {
+					status = blockStatusInvalid
+				} else if i.Status == blockStatusHeader && status == blockStatusValid {
+					status = blockStatusHeader
+				}
+			}
+			switch status {
+			case blockStatusValid:
+				tip.Status = "valid-fork"
+			case blockStatusHeader:
+				tip.Status = "valid-headers"
+			default:
+				tip.Status = "invalid"
+			}
+		}
+		tips = append(tips, tip)
+	}
+
+	sort.Slice(tips, func(i, j int) bool {
+		if tips[i].Height != tips[j].Height {
+			return tips[i].Height > tips[j].Height
+		}
+		return tips[i].BranchLen < tips[j].BranchLen
+	})
+
+	return tips
+}
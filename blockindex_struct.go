@@ -0,0 +1,21 @@
+package main
+
+// 26. 블록 색인의 값
+// 메인 체인과 다른 갈래(side branch)의 모든 블록에 대해 이전 블록, 높이, 누적 작업량(ChainWork)과 검증 상태(Status)를 가짐
+type BlockIndex struct {
+	Hash          []byte
+	PrevBlockHash []byte
+	Height        int
+	Timestamp     int64
+	ChainWork     []byte
+	Status        string
+}
+
+// getchaintips 의 결과
+// Status 는 active(메인 체인), valid-fork(검증된 다른 갈래), valid-headers(작업증명만 검증된 다른 갈래), invalid 중 하나
+type ChainTip struct {
+	Height    int    `json:"height"`
+	Hash      string `json:"hash"`
+	BranchLen int    `json:"branchlen"`
+	Status    string `json:"status"`
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// prev 다음에 코인베이스만 담은 블록을 채굴하여 AcceptBlock 으로 추가하기 위한 테스트 함수
+func acceptTestBlock(t *testing.T, bc *Blockchain, prev *Block, address string) *Block {
+	t.Helper()
+
+	mtp, err := bc.MedianTimePast(prev.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coinbase := NewCoinbaseTX("", address, prev.Height+1, 0)
+	block := newBlockAt([]*Transaction{coinbase}, prev.Hash, prev.Height+1, mtp+1)
+	if _, err := bc.AcceptBlock(block); err != nil {
+		t.Fatal(err)
+	}
+
+	return block
+}
+
+func chainTipStatus(bc *Blockchain) map[string]string {
+	status := make(map[string]string)
+	for _, tip := range bc.GetChainTips() {
+		status[tip.Hash] = tip.Status
+	}
+
+	return status
+}
+
+func TestInvalidateBlockKeepsActiveTip(t *testing.T) {
+	bc := newTestBlockchain(t)
+	blocks, err := bc.Generate(3, newTestAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bc.InvalidateBlock(blocks[1].Hash); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		hex.EncodeToString(blocks[0].Hash): "active",
+		hex.EncodeToString(blocks[2].Hash): "invalid",
+	}
+	got := chainTipStatus(bc)
+	if len(got) != len(want) {
+		t.Fatalf("chain tips = %v, want %v", got, want)
+	}
+	for hash, status := range want {
+		if got[hash] != status {
+			t.Fatalf("chain tips = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestInvalidateBlockActivatesHeavierFork(t *testing.T) {
+	bc := newTestBlockchain(t)
+	chain, err := bc.Generate(3, newTestAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 높이 1 에서 갈라진 길이 2 의 갈래, 메인 체인과 작업량이 같으므로 다른 갈래로 남음
+	address := newTestAddress()
+	fork := chain[0]
+	for i := 0; i < 2; i++ {
+		fork = acceptTestBlock(t, bc, fork, address)
+	}
+	if !bytes.Equal(bc.Tip(), chain[2].Hash) {
+		t.Fatalf("tip = %x, want %x", bc.Tip(), chain[2].Hash)
+	}
+
+	disconnected, err := bc.InvalidateBlock(chain[1].Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(disconnected) != 2 {
+		t.Fatalf("disconnected %d blocks, want 2", len(disconnected))
+	}
+	if !bytes.Equal(bc.Tip(), fork.Hash) {
+		t.Fatalf("tip = %x, want fork %x", bc.Tip(), fork.Hash)
+	}
+	if status := chainTipStatus(bc)[hex.EncodeToString(fork.Hash)]; status != "active" {
+		t.Fatalf("fork status = %q, want active", status)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// 36. 블록 시간 검증
+// 블록의 시간(Timestamp)을 검증하지 않아 채굴자가 과거나 먼 미래의 시간으로 블록을 만들 수 있었음
+//   - median-time-past : 블록의 시간은 이전 medianTimeBlocks(11) 개 블록 시간의 중앙값보다 커야 함
+//   - 미래 시간 : 블록의 시간은 현재 시간(currentTime, regtest 의 mocktime 포함) + maxFutureBlockTime(2시간)을 넘을 수 없음
+//
+// 받은 블록(AcceptBlock)과 경량 클라이언트가 받은 블록 헤더(AddHeader)를 검증하며,
+// 채굴하는 블록(addBlock)은 median-time-past 보다 큰 시간을 사용
+// 이미 저장된 블록은 다시 검증하지 않음
+const (
+	medianTimeBlocks   = 11
+	maxFutureBlockTime = 2 * 60 * 60
+)
+
+var (
+	ErrBlockTimeTooOld = errors.New("block time is not after median time past")
+	ErrBlockTimeTooNew = errors.New("block time is too far in the future")
+)
+
+// 블록 시간들의 중앙값, 개수가 짝수라면 가운데 두 값 중 큰 값
+func medianTime(timestamps []int64) (int64, error) {
+	if len(timestamps) == 0 {
+		return 0, errors.New("no block times for median time past")
+	}
+
+	sorted := append([]int64{}, timestamps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return sorted[len(sorted)/2], nil
+}
+
+// 이전 블록들의 median-time-past 로 블록의 시간을 검증하기 위한 함수
+func checkBlockTime(hash []byte, timestamp, medianTimePast int64) error {
+	if timestamp <= medianTimePast {
+		return fmt.Errorf("%w: block %x has time %d, median time past is %d", ErrBlockTimeTooOld, hash, timestamp, medianTimePast)
+	}
+	if limit := currentTime() + maxFutureBlockTime; timestamp > limit {
+		return fmt.Errorf("%w: block %x has time %d, limit is %d", ErrBlockTimeTooNew, hash, timestamp, limit)
+	}
+
+	return nil
+}
+
+// hash 블록과 그 이전 블록들, 최대 medianTimeBlocks 개의 median-time-past 를 블록 색인으로 구하기 위한 메서드
+// 블록 색인에 없는 블록이라면 오류
+func (bc *Blockchain) MedianTimePast(hash []byte) (int64, error) {
+	var timestamps []int64
+
+	err := bc.db.View(func(tx StorageTx) error {
+		for index := getBlockIndexTx(tx, hash); index != nil && len(timestamps) < medianTimeBlocks; index = getBlockIndexTx(tx, index.PrevBlockHash) {
+			timestamps = append(timestamps, index.Timestamp)
+			if len(index.PrevBlockHash) == 0 {
+				break
+			}
+		}
+
+		if len(timestamps) == 0 {
+			return fmt.Errorf("block %x is not indexed", hash)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return medianTime(timestamps)
+}
+
+// 마지막 블록 다음에 채굴할 블록의 시간, 현재 시간이 median-time-past 이하라면 median-time-past + 1
+func (bc *Blockchain) nextBlockTime() (int64, error) {
+	now := currentTime()
+	if len(bc.l) == 0 {
+		return now, nil
+	}
+	mtp, err := bc.MedianTimePast(bc.l)
+	if err != nil {
+		return 0, err
+	}
+	if now <= mtp {
+		return mtp + 1, nil
+	}
+
+	return now, nil
+}
+
+// 경량 클라이언트가 저장한 블록 헤더들로 median-time-past 를 구하기 위한 함수, 저장된 헤더가 아니라면 오류
+func lightMedianTimePast(tx StorageTx, hash []byte) (int64, error) {
+	var timestamps []int64
+	for header := getLightHeaderTx(tx, hash); header != nil && len(timestamps) < medianTimeBlocks; header = getLightHeaderTx(tx, header.PrevBlockHash) {
+		timestamps = append(timestamps, header.Timestamp)
+		if len(header.PrevBlockHash) == 0 {
+			break
+		}
+	}
+	if len(timestamps) == 0 {
+		return 0, fmt.Errorf("header %x is not stored", hash)
+	}
+
+	return medianTime(timestamps)
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMedianTime(t *testing.T) {
+	tests := []struct {
+		name       string
+		timestamps []int64
+		want       int64
+	}{
+		{"one", []int64{5}, 5},
+		{"odd", []int64{3, 1, 2}, 2},
+		{"even takes the larger middle", []int64{4, 1, 3, 2}, 3},
+		{"duplicates", []int64{7, 7, 1, 7}, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := medianTime(tt.timestamps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("medianTime(%v) = %d, want %d", tt.timestamps, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := medianTime(nil); err == nil {
+		t.Fatal("medianTime(nil) returned no error")
+	}
+}
+
+// mocktime 을 높이마다 10초씩 늘려 블록 n 개를 채굴하기 위한 테스트 함수
+func generateTestBlocksAt(t *testing.T, bc *Blockchain, n int, start int64) []*Block {
+	t.Helper()
+
+	var blocks []*Block
+	for i := 0; i < n; i++ {
+		if err := SetMockTime(start + int64(i)*10); err != nil {
+			t.Fatal(err)
+		}
+		generated, err := bc.Generate(1, newTestAddress())
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, generated...)
+	}
+
+	return blocks
+}
+
+// median-time-past 는 마지막 medianTimeBlocks(11) 개 블록 시간의 중앙값
+func TestMedianTimePast(t *testing.T) {
+	bc := newTestBlockchain(t)
+	start := netParams.GenesisTimestamp + 1000
+	blocks := generateTestBlocksAt(t, bc, 15, start)
+
+	tests := []struct {
+		name  string
+		block *Block
+		want  int64
+	}{
+		// 제네시스 블록과 블록 1, 2 의 중앙값
+		{"fewer than 11 blocks", blocks[1], start},
+		// 블록 5 ~ 15 의 중앙값은 블록 10
+		{"last 11 blocks", blocks[14], blocks[9].Timestamp},
+		{"window moves with the tip", blocks[13], blocks[8].Timestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bc.MedianTimePast(tt.block.Hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("MedianTimePast(%d) = %d, want %d", tt.block.Height, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := bc.MedianTimePast(make([]byte, 32)); err == nil {
+		t.Fatal("MedianTimePast of an unknown block returned no error")
+	}
+}
+
+// 받은 블록의 시간은 median-time-past 보다 크고 현재 시간(mocktime) + 2시간 이하여야 함
+func TestAcceptBlockTime(t *testing.T) {
+	bc := newTestBlockchain(t)
+	now := netParams.GenesisTimestamp + 100000
+	generateTestBlocksAt(t, bc, 11, now-1000)
+	if err := SetMockTime(now); err != nil {
+		t.Fatal(err)
+	}
+	prev, err := bc.GetBlock(bc.Tip())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mtp, err := bc.MedianTimePast(prev.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		timestamp int64
+		err       error
+	}{
+		{"equal to median time past", mtp, ErrBlockTimeTooOld},
+		{"before median time past", mtp - 1, ErrBlockTimeTooOld},
+		{"before the previous block but after median time past", prev.Timestamp - 1, nil},
+		{"more than two hours ahead", now + maxFutureBlockTime + 1, ErrBlockTimeTooNew},
+		{"two hours ahead", now + maxFutureBlockTime, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coinbase := NewCoinbaseTX(tt.name, newTestAddress(), prev.Height+1, 0)
+			block := newBlockAt([]*Transaction{coinbase}, prev.Hash, prev.Height+1, tt.timestamp)
+			_, err := bc.AcceptBlock(block)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("AcceptBlock() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// 현재 시간(mocktime)이 지나면 너무 먼 미래였던 블록도 받을 수 있음
+func TestAcceptBlockTimeMockTimeDrift(t *testing.T) {
+	bc := newTestBlockchain(t)
+	now := netParams.GenesisTimestamp + 100000
+	if err := SetMockTime(now); err != nil {
+		t.Fatal(err)
+	}
+	prev, err := bc.GetBlock(bc.Tip())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coinbase := NewCoinbaseTX("", newTestAddress(), prev.Height+1, 0)
+	block := newBlockAt([]*Transaction{coinbase}, prev.Hash, prev.Height+1, now+maxFutureBlockTime+60)
+	if _, err := bc.AcceptBlock(block); !errors.Is(err, ErrBlockTimeTooNew) {
+		t.Fatalf("AcceptBlock() error = %v, want %v", err, ErrBlockTimeTooNew)
+	}
+
+	if err := SetMockTime(now + 60); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bc.AcceptBlock(block); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 채굴하는 블록의 시간은 현재 시간(mocktime)이 median-time-past 이하라면 median-time-past + 1
+func TestNextBlockTime(t *testing.T) {
+	bc := newTestBlockchain(t)
+	start := netParams.GenesisTimestamp + 1000
+	blocks := generateTestBlocksAt(t, bc, 11, start)
+	mtp, err := bc.MedianTimePast(bc.Tip())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		mockTime int64
+		want     int64
+	}{
+		{"after median time past", blocks[10].Timestamp + 10, blocks[10].Timestamp + 10},
+		{"equal to median time past", mtp, mtp + 1},
+		{"clock moved back", start - 500, mtp + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetMockTime(tt.mockTime); err != nil {
+				t.Fatal(err)
+			}
+			got, err := bc.nextBlockTime()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("nextBlockTime() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// 경량 클라이언트도 블록 헤더의 시간을 검증하며, 저장되지 않은 헤더의 median-time-past 는 panic 대신 오류
+func TestLightHeaderTime(t *testing.T) {
+	bc := newTestBlockchain(t)
+	blocks := generateTestBlocksAt(t, bc, 3, netParams.GenesisTimestamp+1000)
+
+	lc := OpenLightClient("")
+	t.Cleanup(func() {
+		lc.db.Close()
+		RemoveStorage(lightStorage)
+	})
+	genesis := GenesisBlock().Header()
+	if err := lc.AddHeader(&genesis); err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range blocks {
+		header := block.Header()
+		if err := lc.AddHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	coinbase := NewCoinbaseTX("", newTestAddress(), 4, 0)
+	old := newBlockAt([]*Transaction{coinbase}, blocks[2].Hash, 4, blocks[0].Timestamp).Header()
+	if err := lc.AddHeader(&old); !errors.Is(err, ErrBlockTimeTooOld) {
+		t.Fatalf("AddHeader() error = %v, want %v", err, ErrBlockTimeTooOld)
+	}
+
+	err := lc.db.View(func(tx StorageTx) error {
+		_, err := lightMedianTimePast(tx, make([]byte, 32))
+		return err
+	})
+	if err == nil {
+		t.Fatal("lightMedianTimePast of an unknown header returned no error")
+	}
+}
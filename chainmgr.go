@@ -0,0 +1,35 @@
+package main
+
+// 31. 블록체인 동시 접근
+// 마지막 블록해시(l)를 잠금 없이 바꾸었기 때문에 RPC 서버, 채굴, P2P 노드가 하나의 Blockchain 을 함께 사용할 수 없었음
+//   - 쓰기 : AddBlock, MineBlock, SendRawTransaction, AcceptBlock, RollbackTo, InvalidateBlock, ReindexAddresses 는
+//     writeMu 를 잡고 실행하므로 블록체인을 변경하는 작업은 한 번에 하나만 실행됨
+//   - 읽기 : 저장소의 트랜잭션이 일관된 내용을 보여주므로 잠금 없이 실행하며, 마지막 블록해시는 Tip() 으로 복사하여 사용
+//     반복자는 생성 시점의 마지막 블록을 기준으로 순회(스냅샷)
+//   - 마지막 블록해시는 저장소의 트랜잭션이 끝난 뒤에 바꾸므로 다른 고루틴이 저장되지 않은 블록을 보지 않음
+//
+// 다른 프로세스가 블록체인을 쓰고 있다면 storageTimeout 동안 기다린 뒤 ErrDatabaseLocked 로 실패
+
+// 마지막 블록해시를 복사하여 반환하기 위한 메서드, 블록이 없다면 빈 슬라이스
+func (bc *Blockchain) Tip() []byte {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return append([]byte{}, bc.l...)
+}
+
+// 저장소의 트랜잭션이 끝난 뒤 마지막 블록해시를 바꾸기 위한 메서드
+func (bc *Blockchain) setTip(hash []byte) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.l = hash
+}
+
+// 주소 색인이 켜져 있는지 확인하기 위한 메서드
+func (bc *Blockchain) hasAddrIndex() bool {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.addrIndex
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 33. 네트워크(mainnet, testnet, regtest)
+// 합의 규칙과 기본값이 targetBits, subsidy, 주소의 버전 접두어(0x00), 포트 상수로 흩어져 있었음
+// 네트워크마다 ChainParams 를 두고 전역 옵션 -network 로 고른 netParams 를 사용
+//   - mainnet : 기본값
+//   - testnet : 메인넷과 같은 규칙에 다른 주소, 포트, 제네시스 블록을 사용하는 시험용 네트워크
+//   - regtest : 최소 난이도(TargetBits 1)와 짧은 반감기로 혼자 블록을 만들어 시험하기 위한 네트워크
+var (
+	MainNetParams = ChainParams{
+		Name:    "mainnet",
+		DataDir: "",
+
+		AddressVersion: 0x00,
+
+		TargetBits: 16,
+
+		Subsidy:                10,
+		SubsidyHalvingInterval: 210000,
+
+		NodePort: 8333,
+		RPCPort:  8332,
+		RESTPort: 8080,
+
+		GenesisTimestamp: 1704067200, // 2024-01-01 00:00:00 UTC
+		GenesisMessage:   "stbc mainnet genesis block",
+		GenesisNonce:     204263,
+		GenesisHash:      "0000d8f40686303c2f2833f7559493688aeb27096aa59e51b45bbb050cff8af7",
+	}
+
+	TestNetParams = ChainParams{
+		Name:    "testnet",
+		DataDir: "testnet",
+
+		AddressVersion: 0x6f,
+
+		TargetBits: 16,
+
+		Subsidy:                10,
+		SubsidyHalvingInterval: 210000,
+
+		NodePort: 18333,
+		RPCPort:  18332,
+		RESTPort: 18080,
+
+		GenesisTimestamp: 1704067201,
+		GenesisMessage:   "stbc testnet genesis block",
+		GenesisNonce:     22135,
+		GenesisHash:      "00009259058da48ae816d293d4f2a72a4ef8a2e2383c9474b1b8e9360f826ee5",
+	}
+
+	RegTestParams = ChainParams{
+		Name:    "regtest",
+		DataDir: "regtest",
+
+		AddressVersion: 0x6f,
+
+		TargetBits: 1,
+
+		Subsidy:                10,
+		SubsidyHalvingInterval: 150,
+
+		NodePort: 18444,
+		RPCPort:  18443,
+		RESTPort: 18081,
+
+		GenesisTimestamp: 1704067202,
+		GenesisMessage:   "stbc regtest genesis block",
+		GenesisNonce:     1,
+		GenesisHash:      "577050e0bd41985c5e72f292a2c51df2e058ccd4dcd1a108fc6c6c72c5778dec",
+	}
+)
+
+// 사용 중인 네트워크, -network 옵션으로 바꿈
+var netParams = &MainNetParams
+
+// 이름에 해당하는 네트워크를 사용하도록 하기 위한 함수
+func SelectNetwork(name string) error {
+	var names []string
+	for _, params := range []*ChainParams{&MainNetParams, &TestNetParams, &RegTestParams} {
+		if params.Name == name {
+			netParams = params
+			return nil
+		}
+		names = append(names, params.Name)
+	}
+
+	return fmt.Errorf("unknown network '%s' (%s)", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,30 @@
+package main
+
+// 33. 네트워크별 합의 규칙과 기본값
+// 같은 네트워크의 노드들은 같은 값을 사용해야 하며, 다른 네트워크의 블록과 주소는 서로 사용할 수 없음
+//   - DataDir        : -datadir 아래의 하위 디렉터리(메인넷은 -datadir 그대로)
+//   - AddressVersion : 주소의 버전 접두어, 메인넷은 1 로 시작하고 테스트넷과 regtest 는 m, n 으로 시작
+//   - TargetBits     : 작업증명 난이도
+//   - Subsidy        : 최초 블록 보상, SubsidyHalvingInterval 블록마다 절반으로 줄어듦
+//   - GenesisTimestamp, GenesisMessage : 제네시스 블록의 시간과 코인베이스 데이터
+//   - GenesisNonce, GenesisHash : 34) 미리 채굴해 둔 제네시스 블록의 nonce 와 해시(GenesisBlock)
+type ChainParams struct {
+	Name    string
+	DataDir string
+
+	AddressVersion byte
+
+	TargetBits int
+
+	Subsidy                uint64
+	SubsidyHalvingInterval int
+
+	NodePort int
+	RPCPort  int
+	RESTPort int
+
+	GenesisTimestamp int64
+	GenesisMessage   string
+	GenesisNonce     int64
+	GenesisHash      string
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// 26. UTXO 집합(chainstate)
+// 지금까지 UTXO 는 매번 마지막 블록부터 모든 블록을 순회하여 구하였기 때문에, 블록을 되돌릴(disconnect) 때 되돌릴 상태가 따로 없었음
+// 메인 체인의 모든 UTXO 를 chainstate 버킷에 두고 블록을 연결(connectBlock)할 때 갱신, 해제(disconnectBlock)할 때 되돌림
+//   - 키 : 트랜잭션 ID(32) + 출력 인덱스(4, 빅엔디안), 값 : TXOutput(json)
+//   - 이전 버전에서 만든 블록체인은 열 때 메인 체인을 순회하여 만듬
+const ChainStateBucket = "chainstate"
+
+func utxoKey(txid []byte, vout int) []byte {
+	key := make([]byte, len(txid)+4)
+	copy(key, txid)
+	binary.BigEndian.PutUint32(key[len(txid):], uint32(vout))
+
+	return key
+}
+
+// 블록의 트랜잭션들을 UTXO 집합에 반영하기 위한 함수
+// 입력이 소비한 출력을 지우고 새 출력을 추가
+// 27) 지운 출력들을 블록 되돌리기 기록(BlockUndo)으로 반환
+func updateUTXOSet(tx StorageTx, block *Block) (*BlockUndo, error) {
+	b, err := tx.CreateBucketIfNotExists([]byte(ChainStateBucket))
+	if err != nil {
+		return nil, err
+	}
+
+	undo := &BlockUndo{make([][]TXOutput, len(block.Transactions))}
+	for i, t := range block.Transactions {
+		if !t.IsCoinbase() {
+			for _, in := range t.Vin {
+				key := utxoKey(in.Txid, in.Vout)
+				encoded := b.Get(key)
+				if encoded == nil {
+					return nil, fmt.Errorf("output %x:%d is not in the UTXO set", in.Txid, in.Vout)
+				}
+				var out TXOutput
+				if err := json.Unmarshal(encoded, &out); err != nil {
+					return nil, err
+				}
+				undo.Spent[i] = append(undo.Spent[i], out)
+
+				if err := b.Delete(key); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for vout, out := range t.Vout {
+			encoded, err := json.Marshal(out)
+			if err != nil {
+				return nil, err
+			}
+			if err := b.Put(utxoKey(t.ID, vout), encoded); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return undo, nil
+}
+
+// 블록이 UTXO 집합에 반영한 것을 되돌리기 위한 함수
+// 트랜잭션을 역순으로 처리하며 새 출력을 지우고, 입력이 소비한 출력(spent)을 다시 추가
+// spent 는 블록의 트랜잭션 순서대로 각 입력이 소비한 출력(코인베이스는 nil)
+func revertUTXOSet(tx StorageTx, block *Block, spent [][]TXOutput) error {
+	b := tx.Bucket([]byte(ChainStateBucket))
+
+	for i := len(block.Transactions) - 1; i >= 0; i-- {
+		t := block.Transactions[i]
+		for vout := range t.Vout {
+			if err := b.Delete(utxoKey(t.ID, vout)); err != nil {
+				return err
+			}
+		}
+		if t.IsCoinbase() {
+			continue
+		}
+		for vin, in := range t.Vin {
+			encoded, err := json.Marshal(spent[i][vin])
+			if err != nil {
+				return err
+			}
+			if err := b.Put(utxoKey(in.Txid, in.Vout), encoded); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// 블록 색인(blockindex)과 UTXO 집합(chainstate)이 없는 블록체인을 위해 메인 체인을 순회하여 만들기 위한 함수
+// 블록체인을 여는 bolt 트랜잭션 안에서 호출
+// 27) UTXO 집합을 만들 때 블록 되돌리기 기록도 함께 저장, 되돌리기 기록이 없다면 UTXO 집합부터 다시 만듬
+func ensureChainState(tx StorageTx, l []byte) error {
+	needIndex := tx.Bucket([]byte(BlockIndexBucket)) == nil
+	needUTXO := tx.Bucket([]byte(ChainStateBucket)) == nil || tx.Bucket([]byte(UndoBucket)) == nil
+	if len(l) == 0 || (!needIndex && !needUTXO) {
+		return nil
+	}
+	if needUTXO && tx.Bucket([]byte(ChainStateBucket)) != nil {
+		if err := tx.DeleteBucket([]byte(ChainStateBucket)); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.CreateBucketIfNotExists([]byte(BlockIndexBucket)); err != nil {
+		return err
+	}
+	if _, err := tx.CreateBucketIfNotExists([]byte(ChainStateBucket)); err != nil {
+		return err
+	}
+
+	tip := DeserializeBlock(tx.Bucket([]byte(BlocksBucket)).Get(l))
+	for height := 0; height <= tip.Height; height++ {
+		block, err := getBlockByHeightTx(tx, height)
+		if err != nil {
+			return err
+		}
+		if needIndex {
+			if err := putBlockIndex(tx, block, blockStatusValid); err != nil {
+				return err
+			}
+		}
+		if needUTXO {
+			undo, err := updateUTXOSet(tx, block)
+			if err != nil {
+				return err
+			}
+			if err := putBlockUndo(tx, block.Hash, undo); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// 입력들이 참조하는 출력들만 UTXO 집합에서 찾기 위한 메서드, 키는 txid:idx(Outpoint.String())
+// 소비되었거나 존재하지 않는 출력은 포함하지 않으며, UTXO 집합이 없다면 FindAllUTXO() 에서 찾음
+func (bc *Blockchain) findInputOutputs(vin []TXInput) map[string]TXOutput {
+	outs := make(map[string]TXOutput)
+	found := false
+
+	err := bc.db.View(func(tx StorageTx) error {
+		b := tx.Bucket([]byte(ChainStateBucket))
+		if b == nil {
+			return nil
+		}
+		found = true
+
+		for _, in := range vin {
+			if in.Vout < 0 {
+				continue
+			}
+			encoded := b.Get(utxoKey(in.Txid, in.Vout))
+			if encoded == nil {
+				continue
+			}
+			var out TXOutput
+			if err := json.Unmarshal(encoded, &out); err != nil {
+				return err
+			}
+			outs[Outpoint{in.Txid, in.Vout}.String()] = out
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if !found {
+		UTXO := bc.FindAllUTXO()
+		for _, in := range vin {
+			if out, ok := UTXO[hex.EncodeToString(in.Txid)][in.Vout]; ok {
+				outs[Outpoint{in.Txid, in.Vout}.String()] = out
+			}
+		}
+	}
+
+	return outs
+}
+
+// 트랜잭션의 소비되지 않은 출력이 UTXO 집합에 있는지 확인하기 위한 메서드
+// 키가 txid 로 시작하므로 txid 의 키들만 찾으며, UTXO 집합이 없다면 FindAllUTXO() 에서 찾음
+func (bc *Blockchain) HasUnspentOutputs(txid []byte) bool {
+	if len(txid) == 0 {
+		return false
+	}
+	found, exists := false, false
+
+	err := bc.db.View(func(tx StorageTx) error {
+		b := tx.Bucket([]byte(ChainStateBucket))
+		if b == nil {
+			return nil
+		}
+		exists = true
+
+		return b.ForEachPrefix(txid, func(k, v []byte) error {
+			if len(k) == len(txid)+4 {
+				found = true
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if !exists {
+		return len(bc.FindAllUTXO()[hex.EncodeToString(txid)]) > 0
+	}
+
+	return found
+}
+
+// UTXO 집합에서 모든 UTXO 를 읽기 위한 메서드
+// UTXO 집합이 없다면(읽기 전용으로 연 이전 버전의 블록체인) false 를 반환
+func (bc *Blockchain) readUTXOSet() (map[string]map[int]TXOutput, bool) {
+	UTXO := make(map[string]map[int]TXOutput)
+	found := false
+
+	err := bc.db.View(func(tx StorageTx) error {
+		b := tx.Bucket([]byte(ChainStateBucket))
+		if b == nil {
+			return nil
+		}
+		found = true
+
+		return b.ForEach(func(k, v []byte) error {
+			var out TXOutput
+			if err := json.Unmarshal(v, &out); err != nil {
+				return err
+			}
+			txID := hex.EncodeToString(k[:len(k)-4])
+			if UTXO[txID] == nil {
+				UTXO[txID] = make(map[int]TXOutput)
+			}
+			UTXO[txID][int(binary.BigEndian.Uint32(k[len(k)-4:]))] = out
+
+			return nil
+		})
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return UTXO, found
+}
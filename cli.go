@@ -1,14 +1,85 @@
 package main
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
 )
 
-func (c *CLI) createBlockchain(address string) {
-	bc := CreateBlockchain(address)
-	bc.db.Close()
+// 같은 플래그를 여러 번 받기 위한 flag.Value
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// 32) force 라면 기존 블록체인을 지우고 새로 만듬
+// 34) 네트워크의 제네시스 블록으로 만들며, regtest 에서는 address 로 보상을 받는 블록을 하나 채굴(premine)
+func (c *CLI) createBlockchain(address string, addrIndex, force bool) {
+	if address != "" && netParams != &RegTestParams {
+		fmt.Println("-address (premine) is only available on regtest")
+		os.Exit(1)
+	}
+	if address != "" && !ValidateAddress(address) {
+		fmt.Printf("invalid address '%s' for %s\n", address, netParams.Name)
+		os.Exit(1)
+	}
+	if force {
+		c.reset()
+	}
+
+	bc, err := CreateBlockchain(addrIndex)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer bc.db.Close()
+
+	fmt.Printf("Genesis block: %x\n", bc.Tip())
+	if address != "" {
+		block, err := bc.MineBlock(nil, address)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Premined block %d %x to %s\n", block.Height, block.Hash, address)
+	}
+}
+
+// 32. 블록체인을 지우기 위한 Cli 메서드, 지갑(wallet.json)은 남김
+func (c *CLI) reset() {
+	if err := RemoveStorage(chainStorage); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Blockchain removed")
+}
+
+// 19. 주소 색인을 기존 블록체인에 대해 만들기 위한 Cli 메서드
+// 이미 색인이 있다면 지우고 다시 만듬
+func (c *CLI) reindexAddresses() {
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	if err := bc.ReindexAddresses(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Address index rebuilt")
 }
 
 // 새로운 블록을 추가하기 위한 메서드
@@ -22,55 +93,234 @@ func (c *CLI) createBlockchain(address string) {
 
 // 블록체인에 있는 데이터 출력을 위한 메서드
 // 이미 있는 블록체인을 출력하는 것이니 NewBlockchain()을 사용
-func (c *CLI) list() {
+// 21) printchain 명령으로 연결하며 limit, reverse, json 옵션 추가
+func (c *CLI) list(limit int, reverse, asJSON bool) {
 	bc := NewBlockchain()
 	defer bc.db.Close()
 
-	bc.List()
+	bc.List(os.Stdout, limit, reverse, asJSON)
 }
 
 // 어플리케이션 사용을 위한 메서드
+// 25) 명령 앞에 전역 옵션(-datadir)을 받을 수 있도록 변경
+// 28) 전역 옵션 -spv 가 있다면 getbalance, getblockcount 를 경량 클라이언트로 실행
+// 30) 전역 옵션 -storage 로 블록체인을 저장할 저장소(bolt, leveldb, memory)를 선택
+// 33) 전역 옵션 -network 로 네트워크를 선택하며, 블록체인과 지갑은 네트워크별 하위 디렉터리(testnet, regtest)에 둠
+// 35) regtest 에서 전역 옵션 -mocktime 으로 새 블록의 시간을 고정하고, generate 로 블록들을 채굴
 func (c *CLI) Run() {
+	globalCmd := flag.NewFlagSet("stbc", flag.ExitOnError)
+	globalDataDir := globalCmd.String("datadir", ".", "directory for chain.db and wallet.json")
+	globalSPV := globalCmd.String("spv", "", "run as a headers-only light client against this full node (host:port)")
+	globalStorage := globalCmd.String("storage", storageBolt, "storage backend: bolt, leveldb, memory")
+	globalNetwork := globalCmd.String("network", MainNetParams.Name, "network: mainnet, testnet, regtest")
+	globalMockTime := globalCmd.Int64("mocktime", 0, "regtest only: use this unix time for new blocks")
+	globalCmd.Parse(os.Args[1:])
+	args := globalCmd.Args()
+	if len(args) == 0 {
+		globalCmd.Usage()
+		os.Exit(1)
+	}
+	if *globalSPV != "" && !lightCommands[args[0]] {
+		fmt.Printf("%s is not available in light client mode\n", args[0])
+		os.Exit(1)
+	}
+	if err := ValidateStorage(*globalStorage); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	storageBackend = *globalStorage
+	if err := SelectNetwork(*globalNetwork); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *globalMockTime != 0 {
+		if err := SetMockTime(*globalMockTime); err != nil {
+			fmt.Println("-mocktime:", err)
+			os.Exit(1)
+		}
+	}
+	dataDir = filepath.Join(*globalDataDir, netParams.DataDir)
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	newCmd := flag.NewFlagSet("new", flag.ExitOnError)
+	resetCmd := flag.NewFlagSet("reset", flag.ExitOnError)
 	sendCmd := flag.NewFlagSet("send", flag.ExitOnError)
 	getBalanceCmd := flag.NewFlagSet("getbalance", flag.ExitOnError)
 	newWalletCmd := flag.NewFlagSet("newwallet", flag.ExitOnError)
+	supplyCmd := flag.NewFlagSet("supply", flag.ExitOnError)
+	historyCmd := flag.NewFlagSet("history", flag.ExitOnError)
+	printChainCmd := flag.NewFlagSet("printchain", flag.ExitOnError)
+	getBlockCmd := flag.NewFlagSet("getblock", flag.ExitOnError)
+	getBlockCountCmd := flag.NewFlagSet("getblockcount", flag.ExitOnError)
+	getChainTipsCmd := flag.NewFlagSet("getchaintips", flag.ExitOnError)
+	reindexAddrCmd := flag.NewFlagSet("reindexaddr", flag.ExitOnError)
+	scanFiltersCmd := flag.NewFlagSet("scanfilters", flag.ExitOnError)
+	invalidateBlockCmd := flag.NewFlagSet("invalidateblock", flag.ExitOnError)
+	rollbackCmd := flag.NewFlagSet("rollback", flag.ExitOnError)
+	watchCmd := flag.NewFlagSet("watch", flag.ExitOnError)
+	rpcServerCmd := flag.NewFlagSet("rpcserver", flag.ExitOnError)
+	rpcCmd := flag.NewFlagSet("rpc", flag.ExitOnError)
+	restServerCmd := flag.NewFlagSet("restserver", flag.ExitOnError)
+	explorerCmd := flag.NewFlagSet("explorer", flag.ExitOnError)
+	startNodeCmd := flag.NewFlagSet("startnode", flag.ExitOnError)
+	listAddressesCmd := flag.NewFlagSet("listaddresses", flag.ExitOnError)
+	createRawTxCmd := flag.NewFlagSet("createrawtx", flag.ExitOnError)
+	signRawTxCmd := flag.NewFlagSet("signrawtx", flag.ExitOnError)
+	decodeRawTxCmd := flag.NewFlagSet("decoderawtx", flag.ExitOnError)
+	sendRawTxCmd := flag.NewFlagSet("sendrawtx", flag.ExitOnError)
+	generateCmd := flag.NewFlagSet("generate", flag.ExitOnError)
 
 	sendValue := sendCmd.Uint64("value", 0, "")
-	sendFrom := sendCmd.String("from", "", "")
-	sendTo := sendCmd.String("to", "", "")
+	var sendFrom stringsFlag
+	sendCmd.Var(&sendFrom, "from", "wallet address to spend from, can be repeated")
+	sendChange := sendCmd.String("change", "", "address for the change output (default: first -from)")
+	var sendTo stringsFlag
+	sendCmd.Var(&sendTo, "to", "recipient address (with -value) or address:amount, can be repeated")
+	sendFile := sendCmd.String("file", "", "CSV (address,amount) or JSON file of recipients")
+	sendStrategy := sendCmd.String("strategy", defaultCoinSelector, "coin selection strategy: largest, smallest, bnb, random")
+	var sendUTXOs stringsFlag
+	sendCmd.Var(&sendUTXOs, "utxo", "spend this outpoint (txid:idx) first, can be repeated")
+
+	var createRawTxFrom, createRawTxTo, createRawTxUTXOs stringsFlag
+	createRawTxCmd.Var(&createRawTxFrom, "from", "address to spend from, can be repeated")
+	createRawTxCmd.Var(&createRawTxTo, "to", "recipient address (with -value) or address:amount, can be repeated")
+	createRawTxValue := createRawTxCmd.Uint64("value", 0, "")
+	createRawTxFile := createRawTxCmd.String("file", "", "CSV (address,amount) or JSON file of recipients")
+	createRawTxChange := createRawTxCmd.String("change", "", "address for the change output (default: first -from)")
+	createRawTxStrategy := createRawTxCmd.String("strategy", defaultCoinSelector, "coin selection strategy: largest, smallest, bnb, random")
+	createRawTxCmd.Var(&createRawTxUTXOs, "utxo", "spend this outpoint (txid:idx) first, can be repeated")
+	createRawTxOut := createRawTxCmd.String("out", "", "write the raw transaction to this file instead of stdout")
+
+	signRawTxIn := signRawTxCmd.String("in", "", "raw transaction hex or file")
+	signRawTxOut := signRawTxCmd.String("out", "", "write the signed transaction to this file instead of stdout")
+	decodeRawTxIn := decodeRawTxCmd.String("in", "", "raw transaction hex or file")
+	sendRawTxIn := sendRawTxCmd.String("in", "", "raw transaction hex or file")
+
+	printChainJSON := printChainCmd.Bool("json", false, "print blocks as JSON")
+	printChainLimit := printChainCmd.Int("limit", 0, "print at most N blocks (0 for all)")
+	printChainReverse := printChainCmd.Bool("reverse", false, "print from the genesis block instead of the tip")
+
+	getBlockHeight := getBlockCmd.Int("height", -1, "block height")
+	getBlockHash := getBlockCmd.String("hash", "", "block hash")
+	invalidateBlockHash := invalidateBlockCmd.String("hash", "", "block hash")
+	rollbackTo := rollbackCmd.Int("to", -1, "rewind the chain until the block at this height is the tip")
 
-	newAddress := newCmd.String("address", "", "")
+	historyAddress := historyCmd.String("address", "", "")
+	scanFiltersAddress := scanFiltersCmd.String("address", "", "address to find UTXOs for")
+	historySkip := historyCmd.Int("skip", 0, "skip the newest N transactions")
+	historyCount := historyCmd.Int("count", 10, "number of transactions to show (-1 for all)")
+
+	rpcServerPort := rpcServerCmd.Int("port", netParams.RPCPort, "listen on 127.0.0.1:port")
+	rpcServerUser := rpcServerCmd.String("rpcuser", "", "")
+	rpcServerPassword := rpcServerCmd.String("rpcpassword", "", "")
+	rpcServerMiner := rpcServerCmd.String("mineaddress", "", "address receiving block rewards, mines reward blocks even when the mempool is empty")
+	rpcServerInterval := rpcServerCmd.Duration("mineinterval", 10*time.Second, "mine a block at this interval, pending transactions only without -mineaddress (0 disables mining)")
+	restServerPort := restServerCmd.Int("port", netParams.RESTPort, "listen on 127.0.0.1:port")
+	explorerPort := explorerCmd.Int("port", netParams.RESTPort, "listen on 127.0.0.1:port")
+	startNodeHost := startNodeCmd.String("host", "127.0.0.1", "listen and advertise this host")
+	startNodePort := startNodeCmd.Int("port", netParams.NodePort, "listen on host:port for other nodes")
+	var startNodePeers stringsFlag
+	startNodeCmd.Var(&startNodePeers, "peers", "host:port of nodes to connect to, comma separated or repeated")
+	startNodeMiner := startNodeCmd.String("miner", "", "mine blocks (with pending transactions) and send rewards to this address")
+	startNodeInterval := startNodeCmd.Duration("mineinterval", 10*time.Second, "mine a block at this interval")
+	startNodeRPCPort := startNodeCmd.Int("rpcport", 0, "also serve JSON-RPC on 127.0.0.1:rpcport (0 disables)")
+	startNodeRPCUser := startNodeCmd.String("rpcuser", "", "")
+	startNodeRPCPassword := startNodeCmd.String("rpcpassword", "", "")
+	rpcConnect := rpcCmd.String("rpcconnect", fmt.Sprintf("127.0.0.1:%d", netParams.RPCPort), "")
+	rpcUser := rpcCmd.String("rpcuser", "", "")
+	rpcPassword := rpcCmd.String("rpcpassword", "", "")
+
+	watchAddress := watchCmd.String("address", "", "address to watch")
+	watchPubKey := watchCmd.String("pubkey", "", "hex public key to watch")
+
+	newAddress := newCmd.String("address", "", "regtest only: mine a block paying the reward to this address")
+	newAddrIndex := newCmd.Bool("addrindex", false, "maintain an address index (history, faster balance and UTXO lookups)")
+	newForce := newCmd.Bool("force", false, "remove an existing blockchain first")
 	getBalanceAddress := getBalanceCmd.String("address", "", "")
+	generateAddress := generateCmd.String("address", "", "address receiving the block rewards")
 
-	switch os.Args[1] {
+	switch args[0] {
 	case "new":
-		newCmd.Parse(os.Args[2:])
+		newCmd.Parse(args[1:])
+	case "reset":
+		resetCmd.Parse(args[1:])
 	case "send":
-		sendCmd.Parse(os.Args[2:])
+		sendCmd.Parse(args[1:])
 	case "getbalance":
-		getBalanceCmd.Parse(os.Args[2:])
+		getBalanceCmd.Parse(args[1:])
 	case "newwallet":
-		newWalletCmd.Parse(os.Args[2:])
+		newWalletCmd.Parse(args[1:])
+	case "supply":
+		supplyCmd.Parse(args[1:])
+	case "history":
+		historyCmd.Parse(args[1:])
+	case "printchain":
+		printChainCmd.Parse(args[1:])
+	case "getblock":
+		getBlockCmd.Parse(args[1:])
+	case "getblockcount":
+		getBlockCountCmd.Parse(args[1:])
+	case "getchaintips":
+		getChainTipsCmd.Parse(args[1:])
+	case "reindexaddr":
+		reindexAddrCmd.Parse(args[1:])
+	case "scanfilters":
+		scanFiltersCmd.Parse(args[1:])
+	case "invalidateblock":
+		invalidateBlockCmd.Parse(args[1:])
+	case "rollback":
+		rollbackCmd.Parse(args[1:])
+	case "watch":
+		watchCmd.Parse(args[1:])
+	case "rpcserver":
+		rpcServerCmd.Parse(args[1:])
+	case "rpc":
+		rpcCmd.Parse(args[1:])
+	case "restserver":
+		restServerCmd.Parse(args[1:])
+	case "explorer":
+		explorerCmd.Parse(args[1:])
+	case "startnode":
+		startNodeCmd.Parse(args[1:])
+	case "listaddresses":
+		listAddressesCmd.Parse(args[1:])
+	case "createrawtx":
+		createRawTxCmd.Parse(args[1:])
+	case "signrawtx":
+		signRawTxCmd.Parse(args[1:])
+	case "decoderawtx":
+		decodeRawTxCmd.Parse(args[1:])
+	case "sendrawtx":
+		sendRawTxCmd.Parse(args[1:])
+	case "generate":
+		// generate N -address X 와 generate -address X N 을 모두 받음
+		generateCmd.Parse(args[1:])
+		if generateCmd.NArg() > 1 {
+			generateCmd.Parse(append(generateCmd.Args()[1:], generateCmd.Arg(0)))
+		}
 	default:
 		os.Exit(1)
 	}
 
 	if newCmd.Parsed() {
-		if *newAddress == "" {
-			newCmd.Usage()
-			os.Exit(1)
-		}
-		c.createBlockchain(*newAddress)
+		c.createBlockchain(*newAddress, *newAddrIndex, *newForce)
+	}
+	if resetCmd.Parsed() {
+		c.reset()
 	}
 	if sendCmd.Parsed() {
-		if *sendValue == 0 || *sendFrom == "" || *sendTo == "" {
+		if len(sendFrom) == 0 || (len(sendTo) == 0 && *sendFile == "") {
 			sendCmd.Usage()
 			os.Exit(1)
 		}
-		c.send(*sendValue, *sendFrom, *sendTo)
+		c.send(sendFrom, c.recipients(*sendValue, sendTo, *sendFile), *sendChange, *sendStrategy, sendUTXOs)
 	}
-	if getBalanceCmd.Parsed() {
+	if getBalanceCmd.Parsed() && *globalSPV != "" {
+		c.lightBalance(*globalSPV, *getBalanceAddress)
+	} else if getBalanceCmd.Parsed() {
 		if *getBalanceAddress == "" {
 			getBalanceCmd.Usage()
 			os.Exit(1)
@@ -80,30 +330,404 @@ func (c *CLI) Run() {
 	if newWalletCmd.Parsed() {
 		fmt.Printf("Address: %s", c.newWallet())
 	}
+	if supplyCmd.Parsed() {
+		c.supply()
+	}
+	if historyCmd.Parsed() {
+		if *historyAddress == "" || *historySkip < 0 {
+			historyCmd.Usage()
+			os.Exit(1)
+		}
+		c.history(*historyAddress, *historySkip, *historyCount)
+	}
+	if printChainCmd.Parsed() {
+		c.list(*printChainLimit, *printChainReverse, *printChainJSON)
+	}
+	if getBlockCmd.Parsed() {
+		if (*getBlockHeight < 0) == (*getBlockHash == "") {
+			getBlockCmd.Usage()
+			os.Exit(1)
+		}
+		c.getBlock(*getBlockHeight, *getBlockHash)
+	}
+	if getBlockCountCmd.Parsed() && *globalSPV != "" {
+		fmt.Println(c.lightBlockCount(*globalSPV))
+	} else if getBlockCountCmd.Parsed() {
+		fmt.Println(c.getBlockCount())
+	}
+	if getChainTipsCmd.Parsed() {
+		c.getChainTips()
+	}
+	if reindexAddrCmd.Parsed() {
+		c.reindexAddresses()
+	}
+	if scanFiltersCmd.Parsed() {
+		if *scanFiltersAddress == "" {
+			scanFiltersCmd.Usage()
+			os.Exit(1)
+		}
+		c.scanFilters(*scanFiltersAddress)
+	}
+	if invalidateBlockCmd.Parsed() {
+		if *invalidateBlockHash == "" {
+			invalidateBlockCmd.Usage()
+			os.Exit(1)
+		}
+		c.invalidateBlock(*invalidateBlockHash)
+	}
+	if rollbackCmd.Parsed() {
+		if *rollbackTo < 0 {
+			rollbackCmd.Usage()
+			os.Exit(1)
+		}
+		c.rollback(*rollbackTo)
+	}
+	if rpcServerCmd.Parsed() {
+		c.rpcServer(*rpcServerPort, *rpcServerUser, *rpcServerPassword, *rpcServerMiner, *rpcServerInterval)
+	}
+	if rpcCmd.Parsed() {
+		c.rpcClient(*rpcConnect, *rpcUser, *rpcPassword, rpcCmd.Args())
+	}
+	if restServerCmd.Parsed() {
+		c.restServer(*restServerPort)
+	}
+	if explorerCmd.Parsed() {
+		c.explorer(*explorerPort)
+	}
+	if startNodeCmd.Parsed() {
+		c.startNode(*startNodeHost, *startNodePort, startNodePeers, *startNodeMiner, *startNodeInterval, *startNodeRPCPort, *startNodeRPCUser, *startNodeRPCPassword)
+	}
+	if watchCmd.Parsed() {
+		if *watchAddress == "" && *watchPubKey == "" {
+			watchCmd.Usage()
+			os.Exit(1)
+		}
+		fmt.Printf("Watching: %s\n", c.watch(*watchAddress, *watchPubKey))
+	}
+	if listAddressesCmd.Parsed() {
+		c.listAddresses()
+	}
+	if createRawTxCmd.Parsed() {
+		if len(createRawTxFrom) == 0 || (len(createRawTxTo) == 0 && *createRawTxFile == "") {
+			createRawTxCmd.Usage()
+			os.Exit(1)
+		}
+		recipients := c.recipients(*createRawTxValue, createRawTxTo, *createRawTxFile)
+		c.createRawTx(createRawTxFrom, recipients, *createRawTxChange, *createRawTxStrategy, createRawTxUTXOs, *createRawTxOut)
+	}
+	if signRawTxCmd.Parsed() {
+		if *signRawTxIn == "" {
+			signRawTxCmd.Usage()
+			os.Exit(1)
+		}
+		c.signRawTx(*signRawTxIn, *signRawTxOut)
+	}
+	if decodeRawTxCmd.Parsed() {
+		if *decodeRawTxIn == "" {
+			decodeRawTxCmd.Usage()
+			os.Exit(1)
+		}
+		c.decodeRawTx(*decodeRawTxIn)
+	}
+	if sendRawTxCmd.Parsed() {
+		if *sendRawTxIn == "" {
+			sendRawTxCmd.Usage()
+			os.Exit(1)
+		}
+		c.sendRawTx(*sendRawTxIn)
+	}
+	if generateCmd.Parsed() {
+		if generateCmd.NArg() != 1 || *generateAddress == "" {
+			fmt.Println("usage: generate N -address ADDRESS")
+			os.Exit(1)
+		}
+		c.generate(generateCmd.Arg(0), *generateAddress)
+	}
 }
 
 // 거래를 위한 기능
 // 블록 하나당 트랜잭션을 한 개만 가지며, 블록은 채굴되지만 Coinbase Transaction 을 지정해주지 않았기 때문에 보상은 주어지지 않음
-func (c *CLI) send(value uint64, from, to string) {
+// 13) 코인 선택 기능으로 인한 변경점
+//   - strategy 로 코인 선택 전략을, utxos 로 먼저 사용할 UTXO(txid:idx)를 지정
+//
+// 14) 다수의 수신자 기능으로 인한 변경점
+//   - 수신자 목록을 받아 하나의 트랜잭션으로 보냄
+//
+// 15) 다수의 지갑으로 거래하는 기능으로 인한 변경점
+//   - 여러 지갑(from)의 UTXO 를 사용하고 잔액은 change 로 보냄
+func (c *CLI) send(from []string, recipients []Recipient, change, strategy string, utxos []string) {
+	selector, pinned := c.coinSelection(strategy, utxos)
+
 	bc := NewBlockchain()
 	defer bc.db.Close()
 
-	tx := bc.Send(value, from, to)
-	bc.AddBlock([]*Transaction{tx})
+	tx := bc.Send(from, recipients, change, selector, pinned)
+	if _, err := bc.AddBlock([]*Transaction{tx}); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// 코인 선택 전략과 지정(pin)한 UTXO 를 해석하기 위한 메서드
+func (c *CLI) coinSelection(strategy string, utxos []string) (CoinSelector, []Outpoint) {
+	selector, err := NewCoinSelector(strategy)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	var pinned []Outpoint
+	for _, s := range utxos {
+		outpoint, err := ParseOutpoint(s)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		pinned = append(pinned, outpoint)
+	}
+
+	return selector, pinned
+}
+
+// send 명령의 수신자 목록을 구성하기 위한 메서드
+// 기존처럼 -to 하나와 -value 를 받은 경우 그대로 사용하고, 그 외에는 -to 주소:금액 과 -file 의 수신자들을 합침
+func (c *CLI) recipients(value uint64, to []string, file string) []Recipient {
+	var recipients []Recipient
+
+	if len(to) == 1 && value > 0 && file == "" && !strings.Contains(to[0], ":") {
+		to[0] = fmt.Sprintf("%s:%d", to[0], value)
+	} else if value > 0 {
+		fmt.Println("-value can only be used with a single -to address")
+		os.Exit(1)
+	}
+
+	for _, s := range to {
+		r, err := ParseRecipient(s)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		recipients = append(recipients, r)
+	}
+
+	if file != "" {
+		loaded, err := LoadRecipients(file)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		recipients = append(recipients, loaded...)
+	}
+
+	if len(recipients) == 0 {
+		fmt.Println("no recipients")
+		os.Exit(1)
+	}
+
+	return recipients
 }
 
 // 특정 주소의 자금을 보기 위한 기능
 // 특정 주소의 UTXO 의 합을 보여줌
+// 33) 다른 네트워크의 주소는 거부
 func (c *CLI) getBalance(address string) uint64 {
+	if !ValidateAddress(address) {
+		fmt.Printf("invalid address '%s' for %s\n", address, netParams.Name)
+		os.Exit(1)
+	}
+
 	bc := NewBlockchain()
 	defer bc.db.Close()
 
 	return bc.GetBalance(address)
 }
 
+// 20. 높이 또는 해시로 블록을 보기 위한 Cli 메서드
+func (c *CLI) getBlock(height int, hash string) {
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	var block *Block
+	var err error
+	if hash != "" {
+		var h []byte
+		h, err = hex.DecodeString(hash)
+		if err == nil {
+			block, err = bc.GetBlock(h)
+		}
+	} else {
+		block, err = bc.GetBlockByHeight(height)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	NewBlockView(block).Print(os.Stdout)
+}
+
+// 마지막 블록의 높이를 보기 위한 Cli 메서드
+func (c *CLI) getBlockCount() int {
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	return bc.GetBestHeight()
+}
+
+// 26. 메인 체인과 다른 갈래들의 끝 블록을 보기 위한 Cli 메서드
+func (c *CLI) getChainTips() {
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	result, err := json.MarshalIndent(bc.GetChainTips(), "", "  ")
+	if err != nil {
+		log.Panic(err)
+	}
+	fmt.Println(string(result))
+}
+
+// 29. 블록 필터로 주소의 UTXO 를 찾기 위한 Cli 메서드
+// 33) 주소의 버전 접두어가 네트워크와 같은지 ValidateAddress() 로 확인
+func (c *CLI) scanFilters(address string) {
+	if !ValidateAddress(address) {
+		fmt.Printf("invalid address '%s' for %s\n", address, netParams.Name)
+		os.Exit(1)
+	}
+	pubKeyHash, _, _ := base58.CheckDecode(address)
+
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	scan, err := bc.ScanFilters(pubKeyHash)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Scanned %d block filters, fetched %d blocks (%d false positives)\n", scan.Filters, scan.Fetched, scan.FalsePositives)
+	var balance uint64
+	for _, u := range scan.UTXOs {
+		fmt.Printf("  %s %d\n", u.Outpoint, u.Output.Value)
+		balance += u.Output.Value
+	}
+	fmt.Printf("Balance of '%s': %d\n", address, balance)
+}
+
+// 27. 블록을 invalid 로 표시하고 메인 체인에서 해제하기 위한 Cli 메서드
+func (c *CLI) invalidateBlock(hash string) {
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	h, err := hex.DecodeString(hash)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	disconnected, err := bc.InvalidateBlock(h)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Invalidated block %s, disconnected %d blocks, tip is now block %d\n", hash, len(disconnected), bc.GetBestHeight())
+}
+
+// 27. 지정한 높이까지 마지막 블록들을 해제하기 위한 Cli 메서드
+func (c *CLI) rollback(height int) {
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	disconnected, err := bc.RollbackTo(height)
+	for _, block := range disconnected {
+		fmt.Printf("Disconnected block %d %x\n", block.Height, block.Hash)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// 18. 주소의 거래 내역을 보기 위한 Cli 메서드
+// 최신 거래부터 skip 개를 건너뛰고 count 개를 보여줌
+func (c *CLI) history(address string, skip, count int) {
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	history, total, err := bc.GetAddressHistory(address, skip, count)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("History of '%s': %d transactions\n", address, total)
+	for _, h := range history {
+		fmt.Println(h)
+	}
+}
+
+// 17. 감시 전용 지갑을 등록하기 위한 Cli 메서드
+// 주소 또는 공개키(hex)를 받아 개인키 없이 키스토어에 등록하고 주소를 반환
+func (c *CLI) watch(address, pubKeyHex string) string {
+	pubKey, err := hex.DecodeString(pubKeyHex)
+	if err != nil {
+		fmt.Println("invalid public key:", err)
+		os.Exit(1)
+	}
+
+	address, err = NewKeyStore().AddWatchOnly(address, pubKey)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	return address
+}
+
+// 키스토어에 있는 주소들을 보기 위한 Cli 메서드
+func (c *CLI) listAddresses() {
+	var addresses []string
+
+	keyStore := NewKeyStore()
+	for address := range keyStore.Wallets {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	for _, address := range addresses {
+		if keyStore.Wallets[address].IsWatchOnly() {
+			fmt.Printf("%s (watch-only)\n", address)
+		} else {
+			fmt.Println(address)
+		}
+	}
+}
+
 // 지갑을 만들기 위한 Cli 메서드
 // 지갑을 만들고 주소를 반환
 func (c *CLI) newWallet() string {
 	wallet := NewKeyStore().CreateWallet().GetAddress()
 	return wallet
 }
+
+// 지금까지 발행된 코인의 양을 보기 위한 기능
+// UTXO 전체의 합을 발행 일정(ScheduledSupply)과 비교하여 보여줌
+func (c *CLI) supply() {
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	height := bc.GetBestHeight()
+	issued := bc.GetSupply()
+	scheduled := ScheduledSupply(height)
+
+	fmt.Printf("Height: %d\n", height)
+	fmt.Printf("Block subsidy: %d\n", GetBlockSubsidy(height+1))
+	fmt.Printf("Issued (UTXO set): %d\n", issued)
+	fmt.Printf("Scheduled: %d\n", scheduled)
+	fmt.Printf("Max money: %d\n", MaxMoney())
+
+	if issued > scheduled || issued > MaxMoney() {
+		fmt.Println("WARNING: UTXO set exceeds the subsidy schedule")
+		os.Exit(1)
+	}
+}
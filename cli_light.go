@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// 28. 경량 클라이언트 모드(-spv)에서 사용할 수 있는 명령
+// 블록체인(chain.db)을 열지 않는 지갑 명령과 블록 헤더, 머클 증명으로 처리하는 getbalance, getblockcount
+var lightCommands = map[string]bool{
+	"getbalance":    true,
+	"getblockcount": true,
+	"newwallet":     true,
+	"watch":         true,
+	"listaddresses": true,
+}
+
+// 전체 노드에게 블록 헤더를 받은 뒤 가장 많은 작업량을 가진 블록 헤더의 높이를 반환하기 위한 Cli 메서드
+func (c *CLI) lightBlockCount(node string) int {
+	lc := OpenLightClient(node)
+	defer lc.Close()
+
+	if err := lc.Sync(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	return lc.GetBestHeight()
+}
+
+// 머클 증명으로 검증한 잔액을 보기 위한 Cli 메서드
+// address 가 없다면 키스토어의 모든 주소(감시 전용 포함)의 잔액을 보여줌
+func (c *CLI) lightBalance(node, address string) {
+	var addresses []string
+	if address != "" {
+		addresses = append(addresses, address)
+	} else {
+		for address := range NewKeyStore().Wallets {
+			addresses = append(addresses, address)
+		}
+		sort.Strings(addresses)
+	}
+
+	lc := OpenLightClient(node)
+	defer lc.Close()
+
+	balances, err := lc.GetBalances(addresses)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Verified against %d block headers\n", lc.GetBestHeight()+1)
+	for _, address := range addresses {
+		fmt.Printf("Balance of '%s': %d\n", address, balances[address])
+	}
+}
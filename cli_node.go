@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// 25. P2P 노드를 실행하기 위한 Cli 메서드
+// peers 는 처음 연결할 노드들의 주소(host:port)이며 쉼표로 여러 개를 지정할 수 있음
+// 블록체인이 없다면 빈 블록체인으로 시작하여 다른 노드에게 제네시스 블록부터 받음
+// rpcPort 가 0 보다 크면 노드와 블록체인, 메모리풀을 공유하는 RPC 서버를 함께 실행(채굴은 노드가 담당)
+func (c *CLI) startNode(host string, port int, peers []string, minerAddress string, mineInterval time.Duration, rpcPort int, rpcUser, rpcPassword string) {
+	if minerAddress != "" && !ValidateAddress(minerAddress) {
+		fmt.Printf("invalid miner address '%s'\n", minerAddress)
+		os.Exit(1)
+	}
+	if rpcPort > 0 && (rpcUser == "" || rpcPassword == "") {
+		fmt.Println("-rpcuser and -rpcpassword are required with -rpcport")
+		os.Exit(1)
+	}
+
+	var seeds []string
+	for _, p := range peers {
+		for _, addr := range strings.Split(p, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				seeds = append(seeds, addr)
+			}
+		}
+	}
+
+	bc := OpenBlockchain()
+	defer bc.db.Close()
+
+	node := NewNode(bc, fmt.Sprintf("%s:%d", host, port), seeds, minerAddress)
+	if rpcPort > 0 {
+		go func() {
+			if err := node.RPCServer(rpcUser, rpcPassword).ListenAndServe(rpcPort, 0); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	if err := node.Start(mineInterval); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
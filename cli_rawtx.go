@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+// 16. 오프라인 서명을 위한 Cli 메서드
+
+// 서명되지 않은 트랜잭션을 만들어 내보내기 위한 기능(개인키 불필요)
+// 키스토어에 공개키가 있는 주소라면(감시 전용 포함) 입력에 공개키를 넣어둠
+func (c *CLI) createRawTx(from []string, recipients []Recipient, change, strategy string, utxos []string, out string) {
+	selector, pinned := c.coinSelection(strategy, utxos)
+
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	pubKeys := make(map[string][]byte)
+	for address, wallet := range NewKeyStore().Wallets {
+		pubKeys[address] = wallet.PubKey
+	}
+
+	tx, err := bc.CreateTransaction(from, pubKeys, recipients, change, selector, pinned, nil)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	raw, err := bc.NewRawTransaction(tx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	c.writeRawTx(raw, out)
+}
+
+// 키스토어만으로 트랜잭션에 서명하기 위한 기능(chain.db 불필요)
+func (c *CLI) signRawTx(in, out string) {
+	raw := c.readRawTx(in)
+
+	if err := raw.Sign(NewKeyStore().FindKey); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	c.writeRawTx(raw, out)
+}
+
+// 트랜잭션의 내용을 보기 위한 기능(chain.db, 키스토어 불필요)
+func (c *CLI) decodeRawTx(in string) {
+	raw := c.readRawTx(in)
+
+	prevTXs, err := raw.PrevTXMap()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Transaction: %x\n", raw.Tx.ID)
+	for i, vin := range raw.Tx.Vin {
+		prevOut := prevTXs[hex.EncodeToString(vin.Txid)].Vout[vin.Vout]
+		signed := "unsigned"
+		if len(vin.Signature) > 0 {
+			signed = "signed"
+		}
+		fmt.Printf("  Input %d: %x:%d %d from %s (%s)\n", i, vin.Txid, vin.Vout, prevOut.Value, base58.CheckEncode(prevOut.PubKeyHash, netParams.AddressVersion), signed)
+	}
+	for i, vout := range raw.Tx.Vout {
+		fmt.Printf("  Output %d: %d to %s\n", i, vout.Value, base58.CheckEncode(vout.PubKeyHash, netParams.AddressVersion))
+	}
+
+	fee, err := raw.Fee()
+	if err != nil {
+		fmt.Println("  Fee:", err)
+	} else {
+		fmt.Printf("  Fee: %d\n", fee)
+	}
+
+	if raw.IsSigned() {
+		fmt.Println("  Signatures valid:", raw.Tx.Verify(prevTXs))
+	} else {
+		fmt.Println("  Signatures valid: not fully signed")
+	}
+}
+
+// 서명된 트랜잭션을 검증하고 블록에 추가하기 위한 기능
+func (c *CLI) sendRawTx(in string) {
+	raw := c.readRawTx(in)
+
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	if err := bc.SendRawTransaction(raw); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Transaction %x sent\n", raw.Tx.ID)
+}
+
+// in 이 파일이면 파일의 내용을, 아니면 hex 문자열 그대로 읽어 RawTransaction 으로 디코딩
+func (c *CLI) readRawTx(in string) *RawTransaction {
+	if content, err := ioutil.ReadFile(in); err == nil {
+		in = string(content)
+	}
+
+	raw, err := DecodeRawTransaction(in)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	return raw
+}
+
+// out 이 지정되면 파일로, 아니면 표준 출력으로 내보냄
+func (c *CLI) writeRawTx(raw *RawTransaction, out string) {
+	if out == "" {
+		fmt.Println(raw.Encode())
+		return
+	}
+
+	if err := ioutil.WriteFile(out, []byte(raw.Encode()+"\n"), 0644); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
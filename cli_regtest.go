@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// 35. regtest 에서 블록 n 개를 채굴하기 위한 Cli 메서드
+// 채굴한 블록의 높이와 해시를 출력
+func (c *CLI) generate(n string, address string) {
+	count, err := strconv.Atoi(n)
+	if err != nil || count <= 0 {
+		fmt.Printf("invalid number of blocks '%s'\n", n)
+		os.Exit(1)
+	}
+
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	blocks, err := bc.Generate(count, address)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	for _, block := range blocks {
+		fmt.Printf("%d %x\n", block.Height, block.Hash)
+	}
+}
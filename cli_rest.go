@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// 23. REST 서버를 실행하기 위한 Cli 메서드
+// 블록체인(chain.db)을 읽기 전용으로 열기 때문에 서버가 실행되는 동안 블록체인을 변경하는 명령은 사용할 수 없음
+func (c *CLI) restServer(port int) {
+	bc, err := OpenBlockchainReadOnly()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer bc.db.Close()
+
+	if err := NewRESTServer(bc).ListenAndServe(port); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// 24. 웹 블록 탐색기를 실행하기 위한 Cli 메서드, REST API 도 /api/ 아래에서 함께 제공
+func (c *CLI) explorer(port int) {
+	bc, err := OpenBlockchainReadOnly()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer bc.db.Close()
+
+	if err := NewExplorer(bc).ListenAndServe(port); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
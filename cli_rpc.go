@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// 22. JSON-RPC 서버와 클라이언트를 위한 Cli 메서드
+
+// RPC 서버를 실행하기 위한 기능
+// 서버가 실행되는 동안 블록체인(chain.db)을 열어두고 모든 요청이 하나의 Blockchain 을 공유
+func (c *CLI) rpcServer(port int, user, password, minerAddress string, mineInterval time.Duration) {
+	if user == "" || password == "" {
+		fmt.Println("-rpcuser and -rpcpassword are required")
+		os.Exit(1)
+	}
+	if minerAddress != "" && !ValidateAddress(minerAddress) {
+		fmt.Printf("invalid miner address '%s'\n", minerAddress)
+		os.Exit(1)
+	}
+
+	bc := NewBlockchain()
+	defer bc.db.Close()
+
+	server := NewRPCServer(bc, user, password, minerAddress)
+	if err := server.ListenAndServe(port, mineInterval); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// RPC 서버에 요청을 보내고 결과를 출력하기 위한 기능
+func (c *CLI) rpcClient(connect, user, password string, args []string) {
+	if len(args) == 0 {
+		fmt.Println("usage: rpc [options] <method> [params...]")
+		os.Exit(1)
+	}
+
+	result, err := CallRPC("http://"+connect, user, password, args[0], args[1:])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	var out bytes.Buffer
+	if json.Indent(&out, result, "", "  ") != nil {
+		out.Reset()
+		out.Write(result)
+	}
+	fmt.Println(out.String())
+}
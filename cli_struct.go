@@ -1,3 +1,6 @@
 package main
 
 type CLI struct{}
+
+// -utxo 와 같이 여러 번 지정할 수 있는 플래그의 값들
+type stringsFlag []string
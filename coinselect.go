@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 13. 코인 선택(Coin Selection) 전략 추가
+// 기존 Send() 는 UTXO 를 순회 순서대로 합이 보낼 금액 이상이 될 때까지 사용하였기 때문에 잔액이 임의로 만들어 졌음
+// 전략을 선택할 수 있도록 CoinSelector 를 두고, 특정 UTXO 를 직접 지정(pin)하여 사용할 수 있도록 함
+//   - largest  : 큰 금액부터
+//   - smallest : 작은 금액부터
+//   - bnb      : 잔액이 없는 조합 탐색(Branch and Bound), 실패시 largest
+//   - random   : 무작위
+//
+// 먼지(dust) : DustThreshold() 보다 작은 출력은 입력으로 쓰는 비용(서명, 검증)에 비해 가치가 작으므로
+//   - 자동 선택에서 제외(지정한 UTXO 는 사용)
+//   - 잔액이 먼지라면 먼지만큼 더 골라 잔액 출력을 만들고, 더 고를 수 없다면 수수료로 버리지 않고 ErrDustChange(CreateTransaction)
+//   - 기준은 다음 블록의 보상에 따르므로 반감기로 보상이 줄어도 새로 채굴한 코인베이스 출력은 먼지가 아님
+const (
+	defaultCoinSelector = "bnb"
+	dustSubsidyDivisor  = 4 // 블록 보상의 1/4 보다 작은 출력이 먼지
+	bnbMaxTries         = 100000
+)
+
+var (
+	ErrInsufficientFunds = errors.New("NOT enough funds")
+	ErrDustChange        = errors.New("change is dust")
+)
+
+// height 블록의 보상으로 먼지의 기준 금액을 구하기 위한 함수, 최소 1(금액이 0 인 출력만 먼지)
+func DustThreshold(height int) uint64 {
+	if dust := GetBlockSubsidy(height) / dustSubsidyDivisor; dust > 1 {
+		return dust
+	}
+
+	return 1
+}
+
+// 전략 이름으로 CoinSelector 를 얻기 위한 함수
+func NewCoinSelector(name string) (CoinSelector, error) {
+	switch name {
+	case "largest":
+		return LargestFirst{}, nil
+	case "smallest":
+		return SmallestFirst{}, nil
+	case "bnb":
+		return BranchAndBound{bnbMaxTries, LargestFirst{}}, nil
+	case "random":
+		return RandomSelector{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown coin selection strategy '%s' (largest, smallest, bnb, random)", name)
+}
+
+// "txid:idx" 형식의 문자열을 Outpoint 로 변환하기 위한 함수
+func ParseOutpoint(s string) (Outpoint, error) {
+	sep := strings.LastIndex(s, ":")
+	if sep < 0 {
+		return Outpoint{}, fmt.Errorf("invalid outpoint '%s', expected txid:idx", s)
+	}
+
+	txid, err := hex.DecodeString(s[:sep])
+	if err != nil {
+		return Outpoint{}, fmt.Errorf("invalid outpoint txid '%s': %v", s[:sep], err)
+	}
+	vout, err := strconv.Atoi(s[sep+1:])
+	if err != nil || vout < 0 {
+		return Outpoint{}, fmt.Errorf("invalid outpoint index '%s'", s[sep+1:])
+	}
+
+	return Outpoint{txid, vout}, nil
+}
+
+func (o Outpoint) String() string {
+	return fmt.Sprintf("%x:%d", o.Txid, o.Vout)
+}
+
+// UTXO 목록의 합을 구하기 위한 함수
+func sumUTXO(utxos []UTXO) uint64 {
+	var sum uint64
+	for _, u := range utxos {
+		sum += u.Output.Value
+	}
+
+	return sum
+}
+
+// 지정(pin)된 UTXO 를 먼저 사용하고, 부족한 금액은 selector 로 나머지 UTXO 중에서 골라 반환
+// 지정된 UTXO 는 먼지(dust 보다 작은 출력)여도 사용하지만, 지정된 UTXO 가 utxos 에 없다면(이미 소비되었거나 다른 주소의 출력) 오류를 반환
+func SelectCoins(utxos []UTXO, target uint64, selector CoinSelector, pinned []Outpoint, dust uint64) ([]UTXO, error) {
+	var selected, rest []UTXO
+
+	used := make(map[string]bool)
+	for _, o := range pinned {
+		used[o.String()] = false
+	}
+	for _, u := range utxos {
+		if _, ok := used[u.String()]; ok {
+			used[u.String()] = true
+			selected = append(selected, u)
+		} else if u.Output.Value >= dust {
+			rest = append(rest, u)
+		}
+	}
+	for o, found := range used {
+		if !found {
+			return nil, fmt.Errorf("outpoint %s is not spendable", o)
+		}
+	}
+
+	acc := sumUTXO(selected)
+	if acc >= target && len(selected) > 0 {
+		return selected, nil
+	}
+
+	more, err := selector.Select(rest, target-acc)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(selected, more...), nil
+}
+
+// 정렬된 순서대로 합이 target 이상이 될 때까지 UTXO 를 고르는 함수
+func accumulate(utxos []UTXO, target uint64) ([]UTXO, error) {
+	var selected []UTXO
+	var acc uint64
+
+	for _, u := range utxos {
+		if acc >= target && len(selected) > 0 {
+			break
+		}
+		acc += u.Output.Value
+		selected = append(selected, u)
+	}
+
+	if acc < target || len(selected) == 0 {
+		return nil, ErrInsufficientFunds
+	}
+
+	return selected, nil
+}
+
+func (LargestFirst) Select(utxos []UTXO, target uint64) ([]UTXO, error) {
+	sorted := append([]UTXO{}, utxos...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Output.Value > sorted[j].Output.Value
+	})
+
+	return accumulate(sorted, target)
+}
+
+func (SmallestFirst) Select(utxos []UTXO, target uint64) ([]UTXO, error) {
+	sorted := append([]UTXO{}, utxos...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Output.Value < sorted[j].Output.Value
+	})
+
+	return accumulate(sorted, target)
+}
+
+func (RandomSelector) Select(utxos []UTXO, target uint64) ([]UTXO, error) {
+	shuffled := append([]UTXO{}, utxos...)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	return accumulate(shuffled, target)
+}
+
+// 큰 금액부터 깊이 우선으로 포함/제외를 탐색하여 합이 정확히 target 인 조합을 찾음
+// 남은 UTXO 를 모두 더해도 target 에 못 미치거나 이미 target 을 넘은 경우 해당 가지는 더 탐색하지 않음
+func (s BranchAndBound) Select(utxos []UTXO, target uint64) ([]UTXO, error) {
+	sorted := append([]UTXO{}, utxos...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Output.Value > sorted[j].Output.Value
+	})
+
+	// remaining[i] : sorted[i:] 의 합
+	remaining := make([]uint64, len(sorted)+1)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + sorted[i].Output.Value
+	}
+
+	var picked []int
+	tries := 0
+
+	var search func(i int, acc uint64) bool
+	search = func(i int, acc uint64) bool {
+		tries++
+		if acc == target && len(picked) > 0 {
+			return true
+		}
+		if i == len(sorted) || acc > target || acc+remaining[i] < target || tries > s.MaxTries {
+			return false
+		}
+
+		picked = append(picked, i)
+		if search(i+1, acc+sorted[i].Output.Value) {
+			return true
+		}
+		picked = picked[:len(picked)-1]
+
+		return search(i+1, acc)
+	}
+
+	if search(0, 0) {
+		var selected []UTXO
+		for _, i := range picked {
+			selected = append(selected, sorted[i])
+		}
+		return selected, nil
+	}
+
+	if s.Fallback == nil {
+		return nil, errors.New("no exact match found")
+	}
+
+	return s.Fallback.Select(utxos, target)
+}
@@ -0,0 +1,36 @@
+package main
+
+// 트랜잭션 출력의 위치를 나타내기 위한 구조체
+// 트랜잭션 ID 와 그 트랜잭션이 가진 출력의 인덱스로 하나의 출력을 지목함
+type Outpoint struct {
+	Txid []byte
+	Vout int
+}
+
+// 소비되지 않은 하나의 출력(UTXO)과 그 위치
+type UTXO struct {
+	Outpoint
+	Output TXOutput
+}
+
+// 거래에 사용할 UTXO 를 고르기 위한 코인 선택 전략
+// utxos 중에서 합이 target 이상이 되도록 골라 반환하며, 고를 수 없다면 오류를 반환
+type CoinSelector interface {
+	Select(utxos []UTXO, target uint64) ([]UTXO, error)
+}
+
+// 큰 금액의 UTXO 부터 사용 (입력의 수가 가장 적음)
+type LargestFirst struct{}
+
+// 작은 금액의 UTXO 부터 사용 (작은 UTXO 들을 정리)
+type SmallestFirst struct{}
+
+// 잔액(Change)이 생기지 않도록 합이 정확히 target 이 되는 조합을 탐색
+// 조합을 찾지 못하면 Fallback 전략을 사용하며, Fallback 이 없으면 오류를 반환
+type BranchAndBound struct {
+	MaxTries int
+	Fallback CoinSelector
+}
+
+// UTXO 를 무작위 순서로 사용 (주소의 사용 패턴이 드러나지 않도록 함)
+type RandomSelector struct{}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// 금액들로 UTXO 목록을 만들기 위한 테스트 함수, Txid 는 순서대로 0, 1, 2 ...
+func testUTXOs(values ...uint64) []UTXO {
+	var utxos []UTXO
+	for i, v := range values {
+		utxos = append(utxos, UTXO{Outpoint{[]byte{byte(i)}, 0}, TXOutput{Value: v}})
+	}
+
+	return utxos
+}
+
+// 고른 UTXO 의 금액들, 순서와 무관하게 비교하도록 정렬
+func utxoValues(utxos []UTXO) string {
+	var values []int
+	for _, u := range utxos {
+		values = append(values, int(u.Output.Value))
+	}
+	sort.Ints(values)
+
+	return fmt.Sprint(values)
+}
+
+func TestCoinSelectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector CoinSelector
+		utxos    []UTXO
+		target   uint64
+		want     string
+		err      error
+	}{
+		{"largest", LargestFirst{}, testUTXOs(5, 10, 3, 7), 12, "[7 10]", nil},
+		{"largest single", LargestFirst{}, testUTXOs(5, 10, 3, 7), 10, "[10]", nil},
+		{"smallest", SmallestFirst{}, testUTXOs(5, 10, 3, 7), 12, "[3 5 7]", nil},
+		{"bnb exact", BranchAndBound{bnbMaxTries, LargestFirst{}}, testUTXOs(5, 10, 3, 7), 15, "[5 10]", nil},
+		{"bnb exact without largest", BranchAndBound{bnbMaxTries, LargestFirst{}}, testUTXOs(5, 10, 3, 7), 8, "[3 5]", nil},
+		{"bnb fallback", BranchAndBound{bnbMaxTries, LargestFirst{}}, testUTXOs(5, 10, 7), 4, "[10]", nil},
+		{"bnb no fallback", BranchAndBound{bnbMaxTries, nil}, testUTXOs(5, 10, 7), 4, "", errors.New("no exact match found")},
+		{"random all", RandomSelector{}, testUTXOs(5, 10, 3), 18, "[3 5 10]", nil},
+		{"insufficient", LargestFirst{}, testUTXOs(5, 10), 16, "", ErrInsufficientFunds},
+		{"insufficient smallest", SmallestFirst{}, testUTXOs(5, 10), 16, "", ErrInsufficientFunds},
+		{"insufficient random", RandomSelector{}, testUTXOs(5, 10), 16, "", ErrInsufficientFunds},
+		{"empty", LargestFirst{}, nil, 1, "", ErrInsufficientFunds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := tt.selector.Select(tt.utxos, tt.target)
+			if tt.err != nil {
+				if err == nil || err.Error() != tt.err.Error() {
+					t.Fatalf("Select() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := utxoValues(selected); got != tt.want {
+				t.Fatalf("Select() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// 무작위 선택은 순서와 관계없이 합이 target 이상이어야 함
+func TestRandomSelectorCoversTarget(t *testing.T) {
+	utxos := testUTXOs(1, 2, 3, 4, 5, 6, 7, 8, 9)
+	for i := 0; i < 100; i++ {
+		selected, err := RandomSelector{}.Select(utxos, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum := sumUTXO(selected); sum < 20 {
+			t.Fatalf("selected %s with sum %d, want at least 20", utxoValues(selected), sum)
+		}
+	}
+}
+
+func TestSelectCoinsDust(t *testing.T) {
+	const dustThreshold = 3
+	utxos := testUTXOs(1, 2, 10, dustThreshold)
+	dust := Outpoint{[]byte{1}, 0}
+
+	tests := []struct {
+		name   string
+		target uint64
+		pinned []Outpoint
+		want   string
+		err    error
+	}{
+		{"dust is not selected", 12, nil, fmt.Sprintf("[%d 10]", dustThreshold), nil},
+		{"dust does not count towards funds", 14, nil, "", ErrInsufficientFunds},
+		{"pinned dust is used", 5, []Outpoint{dust}, fmt.Sprintf("[2 %d]", dustThreshold), nil},
+		{"pinned dust alone", 2, []Outpoint{dust}, "[2]", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := SelectCoins(utxos, tt.target, SmallestFirst{}, tt.pinned, dustThreshold)
+			if err != tt.err {
+				t.Fatalf("SelectCoins() error = %v, want %v", err, tt.err)
+			}
+			if got := utxoValues(selected); tt.err == nil && got != tt.want {
+				t.Fatalf("SelectCoins() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectCoinsUnknownPin(t *testing.T) {
+	_, err := SelectCoins(testUTXOs(10), 5, LargestFirst{}, []Outpoint{{[]byte{9}, 0}}, 1)
+	if err == nil {
+		t.Fatal("expected an error for a pinned outpoint that is not spendable")
+	}
+}
+
+// 먼지의 기준은 블록 보상을 따라 줄어들며 최소 1
+func TestDustThreshold(t *testing.T) {
+	params := netParams
+	netParams = &RegTestParams
+	defer func() { netParams = params }()
+
+	interval := RegTestParams.SubsidyHalvingInterval
+	tests := []struct {
+		height int
+		want   uint64
+	}{
+		{0, 2},
+		{interval - 1, 2},
+		{interval, 1},
+		{interval * 2, 1},
+		{interval * 64, 1},
+	}
+	for _, tt := range tests {
+		if got := DustThreshold(tt.height); got != tt.want {
+			t.Errorf("DustThreshold(%d) = %d, want %d", tt.height, got, tt.want)
+		}
+	}
+}
+
+// 잔액이 먼지라면 더 골라 잔액 출력을 만들고, 더 고를 수 없다면 수수료로 버리지 않고 ErrDustChange
+func TestCreateTransactionDustChange(t *testing.T) {
+	bc := newTestBlockchain(t)
+	from, to := newTestAddress(), newTestAddress()
+	if _, err := bc.Generate(1, from); err != nil {
+		t.Fatal(err)
+	}
+	reward := GetBlockSubsidy(1)
+	dust := DustThreshold(2)
+
+	tests := []struct {
+		name    string
+		amount  uint64
+		outputs int
+		err     error
+	}{
+		{"dust change", reward - (dust - 1), 0, ErrDustChange},
+		{"change", reward - dust, 2, nil},
+		{"no change", reward, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := bc.CreateTransaction([]string{from}, nil, []Recipient{{to, tt.amount}}, "", LargestFirst{}, nil, nil)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if len(tx.Vout) != tt.outputs {
+				t.Fatalf("%d outputs, want %d", len(tx.Vout), tt.outputs)
+			}
+			if fee, err := bc.TransactionFee(tx); err != nil || fee != 0 {
+				t.Fatalf("fee = %d (%v), want 0", fee, err)
+			}
+		})
+	}
+
+	// 다른 UTXO 가 있다면 함께 사용하여 먼지가 아닌 잔액 출력을 만듦
+	if _, err := bc.Generate(1, from); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := bc.CreateTransaction([]string{from}, nil, []Recipient{{to, reward - (dust - 1)}}, "", LargestFirst{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.Vin) != 2 || len(tx.Vout) != 2 || tx.Vout[1].Value != reward+dust-1 {
+		t.Fatalf("%d inputs, outputs %v, want 2 inputs and change %d", len(tx.Vin), tx.Vout, reward+dust-1)
+	}
+}
+
+// 두 번의 반감기 이후 채굴한 코인베이스 출력(보상 2)도 자동 선택으로 사용할 수 있음
+func TestCreateTransactionAfterHalving(t *testing.T) {
+	bc := newTestBlockchain(t)
+	if _, err := bc.Generate(RegTestParams.SubsidyHalvingInterval*2-1, newTestAddress()); err != nil {
+		t.Fatal(err)
+	}
+	from, to := newTestAddress(), newTestAddress()
+	blocks, err := bc.Generate(1, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reward := blocks[0].Transactions[0].Vout[0].Value
+	if want := netParams.Subsidy >> 2; reward != want {
+		t.Fatalf("reward at height %d = %d, want %d", blocks[0].Height, reward, want)
+	}
+
+	for _, selector := range []CoinSelector{LargestFirst{}, SmallestFirst{}, BranchAndBound{bnbMaxTries, LargestFirst{}}, RandomSelector{}} {
+		for amount := uint64(1); amount <= reward; amount++ {
+			tx, err := bc.CreateTransaction([]string{from}, nil, []Recipient{{to, amount}}, "", selector, nil, nil)
+			if err != nil {
+				t.Fatalf("%T sending %d: %v", selector, amount, err)
+			}
+			if fee, err := bc.TransactionFee(tx); err != nil || fee != 0 {
+				t.Fatalf("%T sending %d: fee = %d (%v), want 0", selector, amount, fee, err)
+			}
+		}
+	}
+}
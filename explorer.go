@@ -0,0 +1,156 @@
+package main
+
+import (
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// 24. 웹 블록 탐색기
+// 블록체인을 볼 수 있는 방법은 Blockchain.List 로 표준출력에 출력하는 것뿐이었기 때문에 바이너리에 HTML 탐색기를 포함
+//   - /                : 최근 블록 목록
+//   - /block/{hash}    : 블록과 블록의 트랜잭션
+//   - /tx/{id}         : 트랜잭션의 입력과 출력, 주소는 주소 페이지로 연결
+//   - /address/{addr}  : 주소의 잔액, UTXO, (주소 색인이 있으면) 거래 내역
+//   - /search?q=       : 블록 해시, 높이, 트랜잭션 ID, 주소 검색
+//   - /api/...         : REST API(rest.go)
+//
+// 템플릿은 explorer 디렉터리에 있으며 embed 로 바이너리에 포함
+const explorerRecentBlocks = 20
+
+//go:embed explorer/*.html
+var explorerFS embed.FS
+
+func NewExplorer(bc *Blockchain) *Explorer {
+	funcs := template.FuncMap{
+		"timestamp": formatTimestamp,
+		"hex":       hex.EncodeToString,
+	}
+	templates := template.Must(template.New("explorer").Funcs(funcs).ParseFS(explorerFS, "explorer/*.html"))
+
+	return &Explorer{bc, NewRESTServer(bc), templates}
+}
+
+// localhost:port 에서 요청을 받기 시작
+func (e *Explorer) ListenAndServe(port int) error {
+	mux := http.NewServeMux()
+	mux.Handle("/api/", http.StripPrefix("/api", e.api))
+	mux.HandleFunc("/", e.serve)
+
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	log.Printf("Explorer listening on http://%s", addr)
+
+	return http.ListenAndServe(addr, mux)
+}
+
+// 경로에 해당하는 페이지를 만들기 위한 메서드
+// 데이터는 REST 서버의 메서드로 조회하며, 오류는 오류 페이지로 보여줌
+func (e *Explorer) serve(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if p := recover(); p != nil {
+			e.render(w, http.StatusInternalServerError, "error", explorerPage{Title: "Error", Data: fmt.Sprint(p)})
+		}
+	}()
+
+	var name, title string
+	var data interface{}
+	var err error
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/":
+		name, title = "index", "Recent blocks"
+		data, err = e.api.blocks(strconv.Itoa(explorerRecentBlocks))
+	case len(parts) == 2 && parts[0] == "block":
+		name, title = "block", "Block "+parts[1]
+		data, err = e.api.blockByHash(parts[1])
+	case len(parts) == 2 && parts[0] == "tx":
+		name, title = "tx", "Transaction "+parts[1]
+		data, err = e.api.transaction(parts[1])
+	case len(parts) == 2 && parts[0] == "address":
+		name, title = "address", "Address "+parts[1]
+		data, err = e.address(parts[1])
+	case r.URL.Path == "/search":
+		e.search(w, r)
+		return
+	default:
+		err = restNotFound("page %s not found", r.URL.Path)
+	}
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		var statusErr restStatusError
+		if errors.As(err, &statusErr) {
+			status = statusErr.status
+		}
+		e.render(w, status, "error", explorerPage{Title: "Error", Data: err.Error()})
+		return
+	}
+
+	e.render(w, http.StatusOK, name, explorerPage{Title: title, Data: data})
+}
+
+func (e *Explorer) render(w http.ResponseWriter, status int, name string, page explorerPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := e.templates.ExecuteTemplate(w, name, page); err != nil {
+		log.Println(err)
+	}
+}
+
+// 주소 페이지의 데이터, 거래 내역은 주소 색인이 없으면 생략
+func (e *Explorer) address(address string) (*AddressPage, error) {
+	UTXOs, err := e.api.addressUTXOs(address)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AddressPage{Address: address, Balance: e.bc.GetBalance(address), UTXOs: UTXOs.([]UTXOView)}
+	if e.bc.hasAddrIndex() {
+		page.History, _, err = e.bc.GetAddressHistory(address, 0, -1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// 검색어의 종류에 따라 알맞은 페이지로 이동
+//   - 주소 : 주소 페이지
+//   - 숫자 : 그 높이의 블록 페이지
+//   - 해시 : 블록이 있으면 블록 페이지, 없으면 트랜잭션 페이지
+func (e *Explorer) search(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	var target string
+	if q == "" {
+		target = "/"
+	} else if ValidateAddress(q) {
+		target = "/address/" + url.PathEscape(q)
+	} else if height, err := strconv.Atoi(q); err == nil {
+		if hash, err := e.bc.GetBlockHash(height); err == nil {
+			target = "/block/" + hex.EncodeToString(hash)
+		}
+	} else if id, err := hex.DecodeString(q); err == nil && len(id) == 32 {
+		if _, err := e.bc.GetBlock(id); err == nil {
+			target = "/block/" + q
+		} else if e.bc.FindTransaction(id) != nil {
+			target = "/tx/" + q
+		}
+	}
+
+	if target == "" {
+		e.render(w, http.StatusNotFound, "error", explorerPage{Title: "Not found", Query: q, Data: fmt.Sprintf("nothing found for '%s'", q)})
+		return
+	}
+
+	http.Redirect(w, r, target, http.StatusFound)
+}
@@ -0,0 +1,26 @@
+package main
+
+import "html/template"
+
+// 24. 웹 블록 탐색기는 읽기 전용으로 연 Blockchain 과 템플릿으로 페이지를 만들고, /api/ 아래는 REST 서버에 맡김
+type Explorer struct {
+	bc        *Blockchain
+	api       *RESTServer
+	templates *template.Template
+}
+
+// 템플릿에 전달하는 페이지 데이터
+// Query 는 검색창에 다시 보여줄 검색어, Data 는 페이지마다 다름(BlockView, TxResult, AddressPage ...)
+type explorerPage struct {
+	Title string
+	Query string
+	Data  interface{}
+}
+
+// 주소 페이지, History 는 주소 색인이 있을 때만 채워짐
+type AddressPage struct {
+	Address string
+	Balance uint64
+	UTXOs   []UTXOView
+	History []HistoryEntry
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// 29. 블록 필터(BIP-158 방식의 GCS, Golomb-coded set)
+// 키로 지갑을 복구하면 FindUnspentTransactions() 로 모든 블록을 읽어야 했음
+// 블록마다 출력의 PubKeyHash 와 입력이 소비한 출력(트랜잭션 ID + 출력 인덱스)을 항목으로 하는 작은 필터를 만들어 filters 버킷에 저장하고,
+// 주소를 찾을 때는 필터를 먼저 검사하여 일치하는 블록만 읽음
+//  1. 항목을 SipHash-2-4(키 : 블록 해시의 앞 16 바이트)로 해싱하여 [0, N*M) 범위로 줄임
+//  2. 정렬한 뒤 이전 값과의 차이를 골롬-라이스 부호화(몫은 1 의 개수 + 0, 나머지는 P 비트)
+//
+// 필터는 거짓 양성(false positive)은 있지만(약 1/M) 거짓 음성은 없음
+const (
+	FiltersBucket = "filters"
+
+	filterP = 19
+	filterM = 784931
+)
+
+// SipHash-2-4, 키는 16 바이트
+func sipHash(key [16]byte, data []byte) uint64 {
+	k0 := binary.LittleEndian.Uint64(key[0:8])
+	k1 := binary.LittleEndian.Uint64(key[8:16])
+
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+
+	round := func() {
+		v0 += v1
+		v1 = bits.RotateLeft64(v1, 13)
+		v1 ^= v0
+		v0 = bits.RotateLeft64(v0, 32)
+		v2 += v3
+		v3 = bits.RotateLeft64(v3, 16)
+		v3 ^= v2
+		v0 += v3
+		v3 = bits.RotateLeft64(v3, 21)
+		v3 ^= v0
+		v2 += v1
+		v1 = bits.RotateLeft64(v1, 17)
+		v1 ^= v2
+		v2 = bits.RotateLeft64(v2, 32)
+	}
+	compress := func(m uint64) {
+		v3 ^= m
+		round()
+		round()
+		v0 ^= m
+	}
+
+	n := len(data) - len(data)%8
+	for i := 0; i < n; i += 8 {
+		compress(binary.LittleEndian.Uint64(data[i:]))
+	}
+	last := uint64(len(data)) << 56
+	for i, b := range data[n:] {
+		last |= uint64(b) << (8 * uint(i))
+	}
+	compress(last)
+
+	v2 ^= 0xff
+	for i := 0; i < 4; i++ {
+		round()
+	}
+
+	return v0 ^ v1 ^ v2 ^ v3
+}
+
+// 항목의 해시를 [0, f) 범위로 줄이기 위한 함수, (hash * f) >> 64
+func hashToRange(key [16]byte, item []byte, f uint64) uint64 {
+	hi, _ := bits.Mul64(sipHash(key, item), f)
+
+	return hi
+}
+
+func filterKey(blockHash []byte) [16]byte {
+	var key [16]byte
+	copy(key[:], blockHash)
+
+	return key
+}
+
+// 항목들로 필터를 만들기 위한 함수, 같은 항목은 한 번만 넣음
+func NewGCSFilter(key [16]byte, items [][]byte) *GCSFilter {
+	seen := make(map[string]bool)
+	var unique [][]byte
+	for _, item := range items {
+		if !seen[string(item)] {
+			seen[string(item)] = true
+			unique = append(unique, item)
+		}
+	}
+
+	filter := &GCSFilter{N: uint64(len(unique)), key: key}
+	values := make([]uint64, len(unique))
+	for i, item := range unique {
+		values[i] = hashToRange(key, item, filter.N*filterM)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	w := &bitWriter{}
+	var last uint64
+	for _, v := range values {
+		delta := v - last
+		last = v
+		for q := delta >> filterP; q > 0; q-- {
+			w.writeBit(1)
+		}
+		w.writeBit(0)
+		w.writeBits(delta, filterP)
+	}
+	filter.Data = w.data
+
+	return filter
+}
+
+// items 중 하나라도 필터에 있는지 확인하기 위한 메서드
+// 찾을 항목들의 해시를 정렬한 뒤 필터의 값들을 차례로 복호화하며 비교
+func (f *GCSFilter) MatchAny(items [][]byte) bool {
+	if f.N == 0 || len(items) == 0 {
+		return false
+	}
+
+	targets := make([]uint64, len(items))
+	for i, item := range items {
+		targets[i] = hashToRange(f.key, item, f.N*filterM)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i] < targets[j] })
+
+	r := &bitReader{data: f.Data}
+	var value uint64
+	t := 0
+	for i := uint64(0); i < f.N; i++ {
+		delta, err := r.readGolomb()
+		if err != nil {
+			return false
+		}
+		value += delta
+
+		for t < len(targets) && targets[t] < value {
+			t++
+		}
+		if t == len(targets) {
+			return false
+		}
+		if targets[t] == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 필터를 저장하기 위한 메서드, 항목 수(가변 길이 정수) + 부호화된 데이터
+func (f *GCSFilter) Serialize() []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, f.N)
+
+	return append(buf[:n], f.Data...)
+}
+
+func DeserializeGCSFilter(key [16]byte, data []byte) (*GCSFilter, error) {
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, errors.New("invalid filter")
+	}
+
+	return &GCSFilter{count, append([]byte{}, data[n:]...), key}, nil
+}
+
+// 블록 필터에 넣을 항목들, 출력의 PubKeyHash 와 (코인베이스가 아닌) 입력이 소비한 출력
+func blockFilterItems(block *Block) [][]byte {
+	var items [][]byte
+	for _, tx := range block.Transactions {
+		if !tx.IsCoinbase() {
+			for _, in := range tx.Vin {
+				items = append(items, utxoKey(in.Txid, in.Vout))
+			}
+		}
+		for _, out := range tx.Vout {
+			if len(out.PubKeyHash) > 0 {
+				items = append(items, out.PubKeyHash)
+			}
+		}
+	}
+
+	return items
+}
+
+// 블록 필터를 만들기 위한 함수
+func NewBlockFilter(block *Block) *GCSFilter {
+	return NewGCSFilter(filterKey(block.Hash), blockFilterItems(block))
+}
+
+// 블록 필터를 만들어 저장하기 위한 함수
+// 블록을 연결하는 같은 bolt 트랜잭션 안에서 호출
+func putBlockFilter(tx StorageTx, block *Block) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(FiltersBucket))
+	if err != nil {
+		return err
+	}
+
+	return b.Put(block.Hash, NewBlockFilter(block).Serialize())
+}
+
+// 블록 필터가 없는 블록체인을 위해 메인 체인을 순회하여 만들기 위한 함수
+// 블록체인을 여는 bolt 트랜잭션 안에서 호출
+func ensureBlockFilters(tx StorageTx, l []byte) error {
+	if len(l) == 0 || tx.Bucket([]byte(FiltersBucket)) != nil {
+		return nil
+	}
+
+	tip := DeserializeBlock(tx.Bucket([]byte(BlocksBucket)).Get(l))
+	for height := 0; height <= tip.Height; height++ {
+		block, err := getBlockByHeightTx(tx, height)
+		if err != nil {
+			return err
+		}
+		if err := putBlockFilter(tx, block); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 블록 필터로 공개키 해시로 잠긴 UTXO 를 찾기 위한 메서드
+// 제네시스 블록부터 각 블록의 필터에 공개키 해시 또는 지금까지 찾은 UTXO 가 있는지 검사하여 일치하는 블록만 읽음
+//   - 출력이 공개키 해시로 잠겨 있다면 UTXO 에 추가
+//   - 입력이 찾은 UTXO 를 소비한다면 UTXO 에서 제거
+func (bc *Blockchain) ScanFilters(pubKeyHash []byte) (*FilterScan, error) {
+	scan := &FilterScan{}
+	utxos := make(map[string]UTXO)
+	best := bc.GetBestHeight()
+
+	err := bc.db.View(func(tx StorageTx) error {
+		filters := tx.Bucket([]byte(FiltersBucket))
+		if filters == nil {
+			return errors.New("no block filters, open the blockchain once to build them")
+		}
+		heights := tx.Bucket([]byte(HeightsBucket))
+		blocks := tx.Bucket([]byte(BlocksBucket))
+
+		for height := 0; height <= best; height++ {
+			hash := heights.Get(heightKey(height))
+			encoded := filters.Get(hash)
+			if encoded == nil {
+				return fmt.Errorf("filter for block %x not found", hash)
+			}
+			filter, err := DeserializeGCSFilter(filterKey(hash), encoded)
+			if err != nil {
+				return err
+			}
+			scan.Filters++
+
+			items := [][]byte{pubKeyHash}
+			for key := range utxos {
+				items = append(items, []byte(key))
+			}
+			if !filter.MatchAny(items) {
+				continue
+			}
+
+			scan.Fetched++
+			scan.Heights = append(scan.Heights, height)
+			relevant := false
+			for _, t := range DeserializeBlock(blocks.Get(hash)).Transactions {
+				if !t.IsCoinbase() {
+					for _, in := range t.Vin {
+						key := string(utxoKey(in.Txid, in.Vout))
+						if _, ok := utxos[key]; ok {
+							delete(utxos, key)
+							relevant = true
+						}
+					}
+				}
+				for vout, out := range t.Vout {
+					if bytes.Equal(out.PubKeyHash, pubKeyHash) {
+						utxos[string(utxoKey(t.ID, vout))] = UTXO{Outpoint{t.ID, vout}, out}
+						relevant = true
+					}
+				}
+			}
+			if !relevant {
+				scan.FalsePositives++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range utxos {
+		scan.UTXOs = append(scan.UTXOs, u)
+	}
+	sort.Slice(scan.UTXOs, func(i, j int) bool {
+		if c := bytes.Compare(scan.UTXOs[i].Txid, scan.UTXOs[j].Txid); c != 0 {
+			return c < 0
+		}
+		return scan.UTXOs[i].Vout < scan.UTXOs[j].Vout
+	})
+
+	return scan, nil
+}
+
+func (w *bitWriter) writeBit(bit uint) {
+	if w.nbits%8 == 0 {
+		w.data = append(w.data, 0)
+	}
+	if bit != 0 {
+		w.data[len(w.data)-1] |= 1 << (7 - w.nbits%8)
+	}
+	w.nbits++
+}
+
+// v 의 아래 n 비트를 높은 비트부터 씀
+func (w *bitWriter) writeBits(v uint64, n uint) {
+	for i := n; i > 0; i-- {
+		w.writeBit(uint(v>>(i-1)) & 1)
+	}
+}
+
+func (r *bitReader) readBit() (uint64, error) {
+	if r.pos >= uint(len(r.data))*8 {
+		return 0, errors.New("filter data is too short")
+	}
+	bit := uint64(r.data[r.pos/8]>>(7-r.pos%8)) & 1
+	r.pos++
+
+	return bit, nil
+}
+
+// 골롬-라이스 부호화된 값 하나를 읽음
+func (r *bitReader) readGolomb() (uint64, error) {
+	var q uint64
+	for {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if bit == 0 {
+			break
+		}
+		q++
+	}
+
+	var rem uint64
+	for i := 0; i < filterP; i++ {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		rem = rem<<1 | bit
+	}
+
+	return q<<filterP | rem, nil
+}
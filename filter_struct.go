@@ -0,0 +1,34 @@
+package main
+
+// 29. 블록 필터(GCS, Golomb-coded set)
+// N 은 필터에 들어간 항목 수, Data 는 골롬-라이스 부호화된 해시 값들
+// key 는 SipHash 의 키로 블록 해시의 앞 16 바이트이며 저장하지 않음
+type GCSFilter struct {
+	N    uint64
+	Data []byte
+	key  [16]byte
+}
+
+// scanfilters 의 결과
+//   - Filters        : 검사한 블록 필터 수
+//   - Fetched        : 필터가 일치하여 읽은 블록 수
+//   - FalsePositives : 읽었지만 주소와 관련된 트랜잭션이 없었던 블록 수
+//   - Heights        : 읽은 블록들의 높이
+type FilterScan struct {
+	Filters        int
+	Fetched        int
+	FalsePositives int
+	Heights        []int
+	UTXOs          []UTXO
+}
+
+// 골롬-라이스 부호화를 위한 비트 단위 쓰기, 읽기
+type bitWriter struct {
+	data  []byte
+	nbits uint
+}
+
+type bitReader struct {
+	data []byte
+	pos  uint
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+// i 번째 테스트 항목, 공개키 해시와 같은 20 바이트
+func testFilterItem(i int) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(i))
+	hash := sha256.Sum256(buf[:])
+
+	return hash[:20]
+}
+
+// 필터를 저장했다가 다시 읽어도 넣은 항목들은 모두 일치하고, 같은 항목은 한 번만 셈
+func TestGCSFilterRoundTrip(t *testing.T) {
+	key := filterKey(testFilterItem(-1))
+
+	for _, n := range []int{0, 1, 2, 10, 1000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			var items [][]byte
+			for i := 0; i < n; i++ {
+				items = append(items, testFilterItem(i))
+			}
+			if n > 0 {
+				items = append(items, testFilterItem(0))
+			}
+
+			filter, err := DeserializeGCSFilter(key, NewGCSFilter(key, items).Serialize())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filter.N != uint64(n) {
+				t.Fatalf("N = %d, want %d", filter.N, n)
+			}
+			for i, item := range items {
+				if !filter.MatchAny([][]byte{item}) {
+					t.Fatalf("item %d does not match", i)
+				}
+			}
+			if n > 0 && !filter.MatchAny([][]byte{testFilterItem(-2), items[n-1]}) {
+				t.Fatal("MatchAny with one member does not match")
+			}
+			if filter.MatchAny(nil) {
+				t.Fatal("MatchAny(nil) matched")
+			}
+		})
+	}
+
+	if _, err := DeserializeGCSFilter(key, nil); err == nil {
+		t.Fatal("deserialized an empty filter")
+	}
+}
+
+// 거짓 양성 비율은 약 1/filterM 이므로, 넣지 않은 항목 5 만 개 중 일치하는 항목은 거의 없어야 함
+func TestGCSFilterFalsePositives(t *testing.T) {
+	const members, queries = 100, 50000
+
+	var items [][]byte
+	for i := 0; i < members; i++ {
+		items = append(items, testFilterItem(i))
+	}
+	filter := NewGCSFilter(filterKey(testFilterItem(-1)), items)
+
+	falsePositives := 0
+	for i := members; i < members+queries; i++ {
+		if filter.MatchAny([][]byte{testFilterItem(i)}) {
+			falsePositives++
+		}
+	}
+	// 기대값은 queries / filterM (약 0.06)
+	if falsePositives > 3 {
+		t.Fatalf("%d false positives in %d queries, expected about %.2f", falsePositives, queries, float64(queries)/filterM)
+	}
+}
+
+// 주소로 받은 블록과 주소의 UTXO 를 소비한 블록만 읽고, 남은 UTXO 를 찾음
+func TestScanFilters(t *testing.T) {
+	bc := newTestBlockchain(t)
+	wallet, other := NewWallet(), newTestAddress()
+	pubKeyHash := HashPubKey(wallet.PubKey)
+
+	generate := func(n int, address string) {
+		t.Helper()
+		if _, err := bc.Generate(n, address); err != nil {
+			t.Fatal(err)
+		}
+	}
+	generate(2, other)
+	generate(1, wallet.GetAddress())
+	generate(2, other)
+	spend, err := bc.AddBlock([]*Transaction{newTestTransaction(t, bc, wallet, wallet, other, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	generate(1, other)
+
+	scan, err := bc.ScanFilters(pubKeyHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scan.Filters != bc.GetBestHeight()+1 {
+		t.Fatalf("scanned %d filters, want %d", scan.Filters, bc.GetBestHeight()+1)
+	}
+	if got := fmt.Sprint(scan.Heights); scan.FalsePositives == 0 && got != fmt.Sprint([]int{3, spend.Height}) {
+		t.Fatalf("fetched blocks %s, want [3 %d]", got, spend.Height)
+	}
+	if scan.Fetched != len(scan.Heights) || scan.Fetched-scan.FalsePositives != 2 {
+		t.Fatalf("fetched %d blocks with %d false positives, want 2 relevant blocks", scan.Fetched, scan.FalsePositives)
+	}
+
+	want := bc.FindUTXO(pubKeyHash)
+	if len(scan.UTXOs) != 1 || len(want) != 1 || scan.UTXOs[0].Output.Value != want[0].Value {
+		t.Fatalf("UTXOs = %v, want %v", scan.UTXOs, want)
+	}
+	if string(scan.UTXOs[0].Txid) != string(spend.Transactions[0].ID) {
+		t.Fatalf("UTXO %s is not the change of %x", scan.UTXOs[0].Outpoint, spend.Transactions[0].ID)
+	}
+
+	unused, err := bc.ScanFilters(HashPubKey(NewWallet().PubKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unused.Fetched != unused.FalsePositives || len(unused.UTXOs) != 0 {
+		t.Fatalf("unused address fetched %d blocks (%d false positives) and found %d UTXOs", unused.Fetched, unused.FalsePositives, len(unused.UTXOs))
+	}
+}
+
+// 다른 네트워크(메인넷)의 주소는 regtest 에서 유효하지 않음(scanfilters 도 ValidateAddress 로 확인)
+func TestValidateAddressNetwork(t *testing.T) {
+	newTestBlockchain(t)
+	wallet := NewWallet()
+	address := wallet.GetAddress()
+
+	netParams = &MainNetParams
+	mainnet := wallet.GetAddress()
+	netParams = &RegTestParams
+
+	if !ValidateAddress(address) {
+		t.Fatalf("regtest address %s is not valid on regtest", address)
+	}
+	if ValidateAddress(mainnet) {
+		t.Fatalf("mainnet address %s is valid on regtest", mainnet)
+	}
+}
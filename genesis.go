@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// 34. 고정된 제네시스 블록
+// CreateBlockchain 이 new -address 의 주소에 보상을 주는 제네시스 블록을 현재 시간으로 채굴했기 때문에 설치마다 다른 블록체인이 만들어졌음
+// 네트워크마다 제네시스 블록의 시간, 코인베이스 데이터, nonce, 해시를 ChainParams 에 두고
+//   - 블록체인을 만들 때(new, startnode) 이 블록을 저장하고, 열 때 높이 0 의 블록이 같은지 확인
+//   - 제네시스 블록의 보상은 어떤 공개키의 해시도 아닌 genesisPubKeyHash 로 잠겨 있어 사용할 수 없음
+//   - new -address 는 regtest 에서만 제네시스 블록 다음 블록을 채굴하여 주소에 보상을 미리 지급(premine)
+//   - 메인넷과 테스트넷의 코인은 startnode -miner, rpcserver -mineaddress 의 채굴 주기마다 채굴하는 블록의 보상으로 만들어짐
+var genesisPubKeyHash = make([]byte, 20)
+
+var ErrGenesisMismatch = errors.New("genesis block does not match the network")
+
+// 네트워크의 제네시스 블록을 만들기 위한 함수
+// 채굴하지 않고 ChainParams 의 nonce 를 사용하며, 해시가 GenesisHash 와 다르거나 작업증명을 만족하지 않으면 panic
+func GenesisBlock() *Block {
+	coinbase := NewTransaction(
+		[]TXInput{{[]byte{}, -1, nil, []byte(netParams.GenesisMessage)}},
+		[]TXOutput{{netParams.Subsidy, genesisPubKeyHash}},
+	)
+	block := &Block{[]byte{}, nil, netParams.GenesisTimestamp, []*Transaction{coinbase}, netParams.GenesisNonce, 0}
+
+	pow := NewProofOfWork(block)
+	hash := sha256.Sum256(pow.prepareData(block.Nonce))
+	block.Hash = hash[:]
+
+	if hex.EncodeToString(block.Hash) != netParams.GenesisHash {
+		log.Panicf("%s genesis block hash %x does not match %s", netParams.Name, block.Hash, netParams.GenesisHash)
+	}
+	if err := CheckProofOfWork(block); err != nil {
+		log.Panic(err)
+	}
+
+	return block
+}
+
+// 제네시스 블록을 블록, 마지막 블록해시(l), 높이 색인, 블록 색인, UTXO 집합, 되돌리기 기록(undo), 블록 필터, (addrIndex 라면) 주소 색인에 저장하기 위한 함수
+// 블록이 없는 블록체인을 만드는 트랜잭션(CreateBlockchain, OpenBlockchain) 안에서 호출
+func putGenesisBlock(tx StorageTx, genesis *Block, addrIndex bool) error {
+	b := tx.Bucket([]byte(BlocksBucket))
+
+	err := b.Put(genesis.Hash, genesis.Serialize())
+	if err != nil {
+		return err
+	}
+
+	// "l" 키는 마지막 블록해시를 저장합니다.
+	err = b.Put([]byte("l"), genesis.Hash)
+	if err != nil {
+		return err
+	}
+
+	if addrIndex {
+		err = createAddrIndex(tx)
+		if err != nil {
+			return err
+		}
+		err = indexBlockAddresses(tx, genesis, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = putBlockHeight(tx, genesis)
+	if err != nil {
+		return err
+	}
+
+	err = putBlockIndex(tx, genesis, blockStatusValid)
+	if err != nil {
+		return err
+	}
+
+	undo, err := updateUTXOSet(tx, genesis)
+	if err != nil {
+		return err
+	}
+
+	err = putBlockUndo(tx, genesis.Hash, undo)
+	if err != nil {
+		return err
+	}
+
+	return putBlockFilter(tx, genesis)
+}
+
+// 블록체인의 높이 0 블록이 네트워크의 제네시스 블록인지 확인하기 위한 함수
+// 블록이 없는 블록체인은 확인하지 않음
+// 블록이 있지만 높이 색인이 없다면(높이 색인 이전 버전으로 만든 블록체인) 제네시스 블록을 찾을 수 없으므로 ErrGenesisMismatch
+func checkGenesis(tx StorageTx) error {
+	heights := tx.Bucket([]byte(HeightsBucket))
+	if heights == nil {
+		if blocks := tx.Bucket([]byte(BlocksBucket)); blocks != nil && len(blocks.Get([]byte("l"))) > 0 {
+			return fmt.Errorf("%w: blockchain has no height index, it was created by an older version (run 'reset')", ErrGenesisMismatch)
+		}
+		return nil
+	}
+	hash := heights.Get(heightKey(0))
+	if hash == nil {
+		return nil
+	}
+
+	if genesis := GenesisBlock(); !bytes.Equal(hash, genesis.Hash) {
+		return fmt.Errorf("%w: blockchain has %x, %s expects %x (run 'reset')", ErrGenesisMismatch, hash, netParams.Name, genesis.Hash)
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// 새 블록체인은 제네시스 블록의 되돌리기 기록을 가지므로 다시 열 때 UTXO 집합을 다시 만들지 않음
+func TestCreateBlockchainStoresGenesisUndo(t *testing.T) {
+	bc := newTestBlockchain(t)
+
+	err := bc.db.View(func(tx StorageTx) error {
+		if tx.Bucket([]byte(UndoBucket)) == nil {
+			return errors.New("no undo bucket")
+		}
+		_, err := getBlockUndo(tx, GenesisBlock())
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
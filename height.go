@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// 20. 블록 높이 추가
+// 블록에는 높이 정보가 없었고 마지막 블록 해시(l)만 알 수 있었기 때문에 N번째 블록을 찾으려면 마지막 블록부터 거꾸로 순회해야 했음
+// 블록에 높이(Height)를 저장하고 heights 버킷에 높이 -> 블록 해시 색인을 두어 높이로 바로 찾을 수 있도록 함
+// 또한 제네시스 블록부터 순회할 수 있는 정방향 반복자를 추가
+const HeightsBucket = "heights"
+
+// 높이 색인의 키 : 블록 높이(8, 빅엔디안)
+func heightKey(height int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(height))
+
+	return key
+}
+
+// 블록의 높이를 높이 색인에 추가하기 위한 함수
+// 블록을 저장하는 같은 bolt 트랜잭션 안에서 호출
+func putBlockHeight(tx StorageTx, block *Block) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(HeightsBucket))
+	if err != nil {
+		return err
+	}
+
+	return b.Put(heightKey(block.Height), block.Hash)
+}
+
+// 블록체인의 마지막 블록의 높이를 구하기 위한 메서드(제네시스 블록의 높이는 0, 블록이 없다면 -1)
+// 20) 블록을 순회하며 세지 않고 마지막 블록의 Height 를 사용
+func (bc *Blockchain) GetBestHeight() int {
+	tip := bc.Tip()
+	if len(tip) == 0 {
+		return -1
+	}
+
+	block, err := bc.GetBlock(tip)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return block.Height
+}
+
+// 블록 해시로 블록을 가져오기 위한 메서드
+func (bc *Blockchain) GetBlock(hash []byte) (*Block, error) {
+	var block *Block
+
+	err := bc.db.View(func(tx StorageTx) error {
+		encodedBlock := tx.Bucket([]byte(BlocksBucket)).Get(hash)
+		if encodedBlock == nil {
+			return fmt.Errorf("block %x not found", hash)
+		}
+		block = DeserializeBlock(encodedBlock)
+
+		return nil
+	})
+
+	return block, err
+}
+
+// 높이에 해당하는 블록 해시를 구하기 위한 메서드
+func (bc *Blockchain) GetBlockHash(height int) ([]byte, error) {
+	var hash []byte
+
+	err := bc.db.View(func(tx StorageTx) error {
+		b := tx.Bucket([]byte(HeightsBucket))
+		if b != nil && height >= 0 {
+			hash = append([]byte{}, b.Get(heightKey(height))...)
+		}
+		if len(hash) == 0 {
+			return fmt.Errorf("block at height %d not found", height)
+		}
+
+		return nil
+	})
+
+	return hash, err
+}
+
+// 높이에 해당하는 메인 체인의 블록을 트랜잭션 안에서 가져오기 위한 함수
+// 높이 색인이 없는(이전 버전으로 만든) 블록체인이거나 블록이 없다면 에러
+func getBlockByHeightTx(tx StorageTx, height int) (*Block, error) {
+	heights := tx.Bucket([]byte(HeightsBucket))
+	if heights == nil {
+		return nil, errors.New("blockchain has no height index (run 'reset')")
+	}
+	encoded := tx.Bucket([]byte(BlocksBucket)).Get(heights.Get(heightKey(height)))
+	if encoded == nil {
+		return nil, fmt.Errorf("block at height %d not found", height)
+	}
+
+	return DeserializeBlock(encoded), nil
+}
+
+// 높이에 해당하는 블록을 가져오기 위한 메서드
+func (bc *Blockchain) GetBlockByHeight(height int) (*Block, error) {
+	hash, err := bc.GetBlockHash(height)
+	if err != nil {
+		return nil, err
+	}
+
+	return bc.GetBlock(hash)
+}
+
+// 제네시스 블록부터 순회하기 위한 반복자 함수
+// blockchainIterator 와 달리 제네시스 블록 -> 마지막 블록 순서로 조회
+// 31) 하나의 트랜잭션에서 마지막 블록까지의 블록 해시들을 모아 스냅샷으로 사용
+func NewBlockchainForwardIterator(bc *Blockchain) *blockchainForwardIterator {
+	i := &blockchainForwardIterator{db: bc.db}
+	tip := bc.Tip()
+	if len(tip) == 0 {
+		return i
+	}
+
+	err := bc.db.View(func(tx StorageTx) error {
+		heights := tx.Bucket([]byte(HeightsBucket))
+		best := DeserializeBlock(tx.Bucket([]byte(BlocksBucket)).Get(tip)).Height
+		for height := 0; height <= best; height++ {
+			hash := heights.Get(heightKey(height))
+			if hash == nil {
+				return fmt.Errorf("block at height %d not found", height)
+			}
+			i.hashes = append(i.hashes, append([]byte{}, hash...))
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return i
+}
+
+// 모아둔 블록 해시로 다음 블록을 조회
+func (i *blockchainForwardIterator) Next() *Block {
+	var block *Block
+
+	err := i.db.View(func(tx StorageTx) error {
+		block = DeserializeBlock(tx.Bucket([]byte(BlocksBucket)).Get(i.hashes[0]))
+		i.hashes = i.hashes[1:]
+
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return block
+}
+
+// 다음 블록이 존재하는지 검사하기 위한 메서드
+func (i *blockchainForwardIterator) HasNext() bool {
+	return len(i.hashes) > 0
+}
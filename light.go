@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+// 28. 경량(SPV, Simplified Payment Verification) 클라이언트
+// 전체 블록체인(chain.db)을 가지지 않고 블록 헤더만 headers.db 에 저장
+//  1. 전체 노드에게 getheaders 로 블록 헤더들을 받아 이전 블록과의 연결, 높이, 작업증명(ProofOfWork.Validate)을 검증하여 저장
+//  2. 잔액은 getproofs 로 주소의 UTXO 가 속한 트랜잭션과 머클 증명을 받아, 머클 증명으로 계산한 루트가
+//     저장된 블록 헤더의 MerkleRoot 와 같은 트랜잭션의 출력만 더함
+//
+// 전체 노드가 트랜잭션을 만들어 낼 수는 없지만 UTXO 를 숨기거나 이미 소비된 출력을 보낼 수는 있으므로 신뢰할 수 있는 노드에 연결해야 함
+const (
+	lightStorage       = "headers"
+	LightHeadersBucket = "headers"
+
+	lightTimeout = 30 * time.Second
+)
+
+// 경량 클라이언트의 블록 헤더 저장소를 열기 위한 함수
+// node 는 블록 헤더와 머클 증명을 받을 전체 노드의 주소(host:port)
+func OpenLightClient(node string) *LightClient {
+	db, err := OpenStorage(lightStorage, false)
+	if errors.Is(err, ErrDatabaseLocked) {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Panic(err)
+	}
+
+	lc := &LightClient{db: db, node: node}
+	err = db.Update(func(tx StorageTx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(LightHeadersBucket))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(HeightsBucket)); err != nil {
+			return err
+		}
+		lc.l = append([]byte{}, b.Get([]byte("l"))...)
+
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return lc
+}
+
+func getLightHeaderTx(tx StorageTx, hash []byte) *LightHeader {
+	encoded := tx.Bucket([]byte(LightHeadersBucket)).Get(hash)
+	if encoded == nil {
+		return nil
+	}
+
+	var header LightHeader
+	if err := json.Unmarshal(encoded, &header); err != nil {
+		log.Panic(err)
+	}
+
+	return &header
+}
+
+// 블록 해시로 저장된 블록 헤더를 가져오기 위한 메서드, 없다면 nil
+func (lc *LightClient) GetHeader(hash []byte) *LightHeader {
+	var header *LightHeader
+
+	err := lc.db.View(func(tx StorageTx) error {
+		header = getLightHeaderTx(tx, hash)
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return header
+}
+
+// 가장 많은 작업량을 가진 블록 헤더의 높이, 블록 헤더가 없다면 -1
+func (lc *LightClient) GetBestHeight() int {
+	if len(lc.l) == 0 {
+		return -1
+	}
+
+	return lc.GetHeader(lc.l).Height
+}
+
+// 높이에 해당하는 블록 헤더의 해시를 구하기 위한 메서드
+func (lc *LightClient) GetHeaderHash(height int) ([]byte, error) {
+	var hash []byte
+
+	err := lc.db.View(func(tx StorageTx) error {
+		if height >= 0 {
+			hash = append([]byte{}, tx.Bucket([]byte(HeightsBucket)).Get(heightKey(height))...)
+		}
+		if len(hash) == 0 {
+			return fmt.Errorf("header at height %d not found", height)
+		}
+
+		return nil
+	})
+
+	return hash, err
+}
+
+// 블록 헤더를 검증하여 저장하기 위한 메서드
+//   - 이미 가진 블록 헤더는 무시
+//   - 이전 블록 헤더가 있어야 하며(제네시스 블록은 처음 한 번만), 높이는 이전 블록 + 1, 작업증명을 만족해야 함
+//   - 누적 작업량이 가장 많다면 마지막 블록 헤더(l)로 하고 높이 색인을 이 블록 헤더의 갈래로 바꿈
+//
+// 34) 제네시스 블록 헤더는 네트워크의 제네시스 블록(GenesisBlock)과 같아야 함
+// 36) 블록 헤더의 시간은 이전 블록 헤더들의 median-time-past 보다 크고 먼 미래가 아니어야 함
+func (lc *LightClient) AddHeader(h *BlockHeader) error {
+	return lc.db.Update(func(tx StorageTx) error {
+		b := tx.Bucket([]byte(LightHeadersBucket))
+		if b.Get(h.Hash) != nil {
+			return nil
+		}
+
+		work := headerWork(h)
+		if len(h.PrevBlockHash) == 0 {
+			if len(lc.l) > 0 || !bytes.Equal(h.Hash, GenesisBlock().Hash) {
+				return fmt.Errorf("header %x is a different genesis block", h.Hash)
+			}
+			if h.Height != 0 {
+				return fmt.Errorf("genesis header %x has height %d", h.Hash, h.Height)
+			}
+		} else {
+			prev := getLightHeaderTx(tx, h.PrevBlockHash)
+			if prev == nil {
+				return fmt.Errorf("header %x does not connect to a known header", h.Hash)
+			}
+			if h.Height != prev.Height+1 {
+				return fmt.Errorf("header %x has height %d, expected %d", h.Hash, h.Height, prev.Height+1)
+			}
+			work.Add(work, new(big.Int).SetBytes(prev.ChainWork))
+		}
+		if err := CheckHeaderProofOfWork(h); err != nil {
+			return err
+		}
+		if len(h.PrevBlockHash) > 0 {
+			mtp, err := lightMedianTimePast(tx, h.PrevBlockHash)
+			if err != nil {
+				return err
+			}
+			if err := checkBlockTime(h.Hash, h.Timestamp, mtp); err != nil {
+				return err
+			}
+		}
+
+		header := &LightHeader{*h, work.Bytes()}
+		encoded, err := json.Marshal(header)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(h.Hash, encoded); err != nil {
+			return err
+		}
+
+		heights := tx.Bucket([]byte(HeightsBucket))
+		if len(lc.l) > 0 {
+			tip := getLightHeaderTx(tx, lc.l)
+			if work.Cmp(new(big.Int).SetBytes(tip.ChainWork)) <= 0 {
+				return nil
+			}
+			for height := tip.Height; height > h.Height; height-- {
+				if err := heights.Delete(heightKey(height)); err != nil {
+					return err
+				}
+			}
+		}
+		for cur := header; !bytes.Equal(heights.Get(heightKey(cur.Height)), cur.Hash); cur = getLightHeaderTx(tx, cur.PrevBlockHash) {
+			if err := heights.Put(heightKey(cur.Height), cur.Hash); err != nil {
+				return err
+			}
+			if cur.Height == 0 {
+				break
+			}
+		}
+		if err := b.Put([]byte("l"), h.Hash); err != nil {
+			return err
+		}
+		lc.l = h.Hash
+
+		return nil
+	})
+}
+
+// 전체 노드에게 새 블록 헤더들을 받아 저장하기 위한 메서드
+// 한 번에 maxHeaders 개씩 받으며 더 받을 블록 헤더가 없을 때까지 반복
+func (lc *LightClient) Sync() error {
+	c, err := dialLight(lc.node, lc.GetBestHeight())
+	if err != nil {
+		return err
+	}
+	defer c.conn.Close()
+
+	return lc.sync(c)
+}
+
+func (lc *LightClient) sync(c *lightConn) error {
+	for {
+		var m headersMsg
+		locator := blockLocator(lc.GetBestHeight(), lc.GetHeaderHash)
+		if err := c.request("getheaders", getHeadersMsg{locator}, "headers", &m); err != nil {
+			return err
+		}
+		for i := range m.Headers {
+			if err := lc.AddHeader(&m.Headers[i]); err != nil {
+				return err
+			}
+		}
+		if len(m.Headers) < maxHeaders {
+			return nil
+		}
+	}
+}
+
+// 블록 헤더들을 받은 뒤 주소들의 잔액을 구하기 위한 메서드
+// 전체 노드에게 받은 트랜잭션은 verifyTxProof() 로 검증하며, 하나라도 검증에 실패하면 에러
+func (lc *LightClient) GetBalances(addresses []string) (map[string]uint64, error) {
+	c, err := dialLight(lc.node, lc.GetBestHeight())
+	if err != nil {
+		return nil, err
+	}
+	defer c.conn.Close()
+
+	if err := lc.sync(c); err != nil {
+		return nil, err
+	}
+
+	balances := make(map[string]uint64)
+	for _, address := range addresses {
+		pubKeyHash, _, err := base58.CheckDecode(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address '%s': %v", address, err)
+		}
+
+		var m proofsMsg
+		if err := c.request("getproofs", getProofsMsg{[][]byte{pubKeyHash}}, "proofs", &m); err != nil {
+			return nil, err
+		}
+
+		seen := make(map[string]bool)
+		for i := range m.Proofs {
+			p := &m.Proofs[i]
+			if err := lc.verifyTxProof(p); err != nil {
+				return nil, err
+			}
+			for _, vout := range p.Vout {
+				if vout < 0 || vout >= len(p.Tx.Vout) {
+					return nil, fmt.Errorf("transaction %x has no output %d", p.Tx.ID, vout)
+				}
+				outpoint := Outpoint{p.Tx.ID, vout}.String()
+				if seen[outpoint] {
+					continue
+				}
+				seen[outpoint] = true
+
+				out := p.Tx.Vout[vout]
+				if !bytes.Equal(out.PubKeyHash, pubKeyHash) {
+					return nil, fmt.Errorf("output %s is not locked to %s", outpoint, address)
+				}
+				balances[address] += out.Value
+			}
+		}
+	}
+
+	return balances, nil
+}
+
+// 전체 노드에게 받은 트랜잭션이 메인 체인의 블록에 포함되었는지 검증하기 위한 메서드
+//   - 트랜잭션 ID 가 내용의 해시와 같아야 함
+//   - 블록 헤더가 저장되어 있고 가장 많은 작업량을 가진 갈래에 있어야 함
+//   - 머클 증명으로 계산한 루트가 블록 헤더의 MerkleRoot 와 같아야 함
+func (lc *LightClient) verifyTxProof(p *TxProof) error {
+	if p.Tx == nil {
+		return errors.New("proof has no transaction")
+	}
+	if !bytes.Equal(p.Tx.ID, p.Tx.Hash()) {
+		return fmt.Errorf("transaction %x has an invalid id", p.Tx.ID)
+	}
+
+	header := lc.GetHeader(p.BlockHash)
+	if header == nil {
+		return fmt.Errorf("block %x of transaction %x is not in the header chain", p.BlockHash, p.Tx.ID)
+	}
+	if hash, err := lc.GetHeaderHash(header.Height); err != nil || !bytes.Equal(hash, header.Hash) {
+		return fmt.Errorf("block %x of transaction %x is not in the best header chain", p.BlockHash, p.Tx.ID)
+	}
+	if !bytes.Equal(p.Proof.Root(p.Tx.ID), header.MerkleRoot) {
+		return fmt.Errorf("merkle proof of transaction %x does not match block %x", p.Tx.ID, p.BlockHash)
+	}
+
+	return nil
+}
+
+func (lc *LightClient) Close() {
+	lc.db.Close()
+}
+
+// 전체 노드에 연결하여 version/verack 을 주고받기 위한 함수
+// 접속 주소(AddrFrom)를 보내지 않으므로 전체 노드는 경량 클라이언트에게 다시 연결하지 않음
+func dialLight(addr string, height int) (*lightConn, error) {
+	conn, err := net.DialTimeout("tcp", addr, lightTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &lightConn{conn, json.NewEncoder(conn), json.NewDecoder(conn)}
+
+	var v versionMsg
+	if err := c.request("version", versionMsg{protocolVersion, height, nil, ""}, "version", &v); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if v.Version != protocolVersion {
+		conn.Close()
+		return nil, fmt.Errorf("unsupported protocol version %d", v.Version)
+	}
+	if err := c.wait("verack", nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// 메시지를 보내고 reply 메시지를 기다리기 위한 메서드
+func (c *lightConn) request(command string, payload interface{}, reply string, v interface{}) error {
+	msg := message{Command: command}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	msg.Payload = data
+
+	c.conn.SetDeadline(time.Now().Add(lightTimeout))
+	if err := c.enc.Encode(msg); err != nil {
+		return err
+	}
+
+	return c.wait(reply, v)
+}
+
+// command 메시지를 받을 때까지 다른 메시지(inv, getblocks 등)는 무시
+func (c *lightConn) wait(command string, v interface{}) error {
+	for {
+		var msg message
+		if err := c.dec.Decode(&msg); err != nil {
+			return err
+		}
+		if msg.Command != command {
+			continue
+		}
+		if v == nil {
+			return nil
+		}
+
+		return json.Unmarshal(msg.Payload, v)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+)
+
+// 28. 경량(SPV) 클라이언트
+// 블록 전체가 아닌 블록 헤더만 headers.db 에 저장하며, 트랜잭션은 전체 노드(node)에게 머클 증명과 함께 받아 검증
+// l 은 누적 작업량이 가장 많은 블록 헤더의 해시
+type LightClient struct {
+	db   Storage
+	l    []byte
+	node string
+}
+
+// 경량 클라이언트가 저장하는 블록 헤더, 제네시스 블록부터의 누적 작업량(ChainWork)을 함께 가짐
+type LightHeader struct {
+	BlockHeader
+	ChainWork []byte
+}
+
+// 전체 노드와의 연결, 요청을 보내고 원하는 응답이 올 때까지 다른 메시지는 무시
+type lightConn struct {
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+}
@@ -23,22 +23,52 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"math/big"
+	"os"
+	"path/filepath"
 	"strconv"
-	"time"
-
-	"github.com/boltdb/bolt"
 )
 
 const (
 	BlocksBucket = "blocks"
-	dbFile       = "chain.db"
-	targetBits   = 16
+	chainStorage = "chain"
+)
+
+// 32. 블록체인이 없거나(new 를 실행하지 않음) 이미 있을 때의 에러
+var (
+	ErrNoBlockchain     = errors.New("no blockchain found, run 'new' first")
+	ErrBlockchainExists = errors.New("blockchain already exists, use 'new -force' or 'reset' to reinitialize")
 )
 
+// 25. 여러 노드를 한 컴퓨터에서 실행할 수 있도록 블록체인(chain.db)과 지갑(wallet.json)을 둘 디렉터리를 지정
+// -datadir 전역 옵션으로 바꿀 수 있으며 기본값은 현재 디렉터리
+var dataDir = "."
+
+func dataPath(name string) string {
+	return filepath.Join(dataDir, name)
+}
+
+// 31. 블록체인 저장소를 쓰기 위해 열기 위한 함수
+// 다른 프로세스가 쓰고 있어 storageTimeout 안에 열지 못하면 에러를 출력하고 종료
+func openChainStorage() Storage {
+	db, err := OpenStorage(chainStorage, false)
+	if errors.Is(err, ErrDatabaseLocked) {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Println("error msg : ", err.Error())
+		log.Panic(err)
+	}
+
+	return db
+}
+
 func main() {
 	cli := CLI{}
 	cli.Run()
@@ -46,8 +76,19 @@ func main() {
 
 // 8) 트랜잭션 기능으로 인한 변경점
 //   - 기존 입력파라메타의 data를 trasaction으로 변경
-func NewBlock(transactions []*Transaction, prevBlockHash []byte) *Block {
-	block := &Block{prevBlockHash, []byte{}, time.Now().Unix(), transactions, 0}
+//
+// 20) 블록 높이 추가로 인한 변경점
+//   - 입력파라메타 height 추가
+//
+// 35) 블록의 시간은 currentTime() 으로, regtest 의 mocktime 이 있다면 그 시간
+func NewBlock(transactions []*Transaction, prevBlockHash []byte, height int) *Block {
+	return newBlockAt(transactions, prevBlockHash, height, currentTime())
+}
+
+// 33. 블록의 시간을 지정하여 채굴하기 위한 함수
+// 제네시스 블록은 네트워크의 GenesisTimestamp 를 사용
+func newBlockAt(transactions []*Transaction, prevBlockHash []byte, height int, timestamp int64) *Block {
+	block := &Block{prevBlockHash, []byte{}, timestamp, transactions, 0, height}
 	pow := NewProofOfWork(block)
 	block.Nonce, block.Hash = pow.Run()
 
@@ -77,25 +118,43 @@ func IntToHex(int_value int64) []byte {
 //
 // 7) Cli 추가로 인한 변경점
 //   - 기존 이미 블록체인이 존재하는 경우에 대한 Genesis Block 생성은 사라지고 기존의 블록체인이 존재하는 경우, 기존 블록체인을 얻어오기 위해 사용됨
+//
+// 32) 블록체인이 없다면(blocks 버킷이 없음) ErrNoBlockchain 을 출력하고 종료
+// 34) 제네시스 블록이 네트워크의 제네시스 블록과 다르다면 ErrGenesisMismatch 를 출력하고 종료
 func NewBlockchain() *Blockchain {
+	if !StorageExists(chainStorage) {
+		fmt.Println(ErrNoBlockchain)
+		os.Exit(1)
+	}
 
 	blockchain := new(Blockchain)
 	var l []byte
 
-	db, err := bolt.Open(dbFile, 0600, nil)
-	if err != nil {
-		fmt.Println("error msg : ", err.Error())
-		log.Panic(err)
-	}
-
-	err = db.Update(func(tx *bolt.Tx) error {
+	db := openChainStorage()
+	err := db.Update(func(tx StorageTx) error {
 		b := tx.Bucket([]byte(BlocksBucket))
+		if b == nil {
+			return ErrNoBlockchain
+		}
+		if err := checkGenesis(tx); err != nil {
+			return err
+		}
 
 		// 이미 블록체인이 존재하는 경우
-		l = b.Get([]byte("l"))
+		l = append([]byte{}, b.Get([]byte("l"))...)
+		blockchain.addrIndex = addrIndexEnabled(tx)
 
-		return nil
+		if err := ensureChainState(tx, l); err != nil {
+			return err
+		}
+
+		return ensureBlockFilters(tx, l)
 	})
+	if err == ErrNoBlockchain || errors.Is(err, ErrGenesisMismatch) {
+		db.Close()
+		fmt.Println(err)
+		os.Exit(1)
+	}
 	if err != nil {
 		fmt.Println("error msg : ", err.Error())
 		log.Panic(err)
@@ -106,6 +165,83 @@ func NewBlockchain() *Blockchain {
 	return blockchain
 }
 
+// 25. 노드를 위해 블록체인을 여는 함수
+// 블록체인이 없다면 블록이 없는 빈 블록체인을 만들어, 제네시스 블록부터 다른 노드에게 받을 수 있도록 함
+// 34) 블록체인이 없다면 네트워크의 제네시스 블록만 가진 블록체인을 만들고, 제네시스 블록이 다르다면 에러를 출력하고 종료
+func OpenBlockchain() *Blockchain {
+	db := openChainStorage()
+
+	blockchain := &Blockchain{db: db}
+	err := db.Update(func(tx StorageTx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(BlocksBucket))
+		if err != nil {
+			return err
+		}
+		if len(b.Get([]byte("l"))) == 0 {
+			if err := putGenesisBlock(tx, GenesisBlock(), false); err != nil {
+				return err
+			}
+		}
+		if err := checkGenesis(tx); err != nil {
+			return err
+		}
+		blockchain.l = append([]byte{}, b.Get([]byte("l"))...)
+		blockchain.addrIndex = addrIndexEnabled(tx)
+
+		if err := ensureChainState(tx, blockchain.l); err != nil {
+			return err
+		}
+
+		return ensureBlockFilters(tx, blockchain.l)
+	})
+	if errors.Is(err, ErrGenesisMismatch) {
+		db.Close()
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return blockchain
+}
+
+// 23. 블록체인을 읽기 전용으로 열기 위한 함수
+// 조회만 하는 서버(REST)는 저장소를 ReadOnly 로 열기 때문에 블록체인을 변경할 수 없음
+// 32) 저장소나 blocks 버킷이 없다면 ErrNoBlockchain
+// 34) 제네시스 블록이 네트워크의 제네시스 블록과 다르다면 ErrGenesisMismatch
+func OpenBlockchainReadOnly() (*Blockchain, error) {
+	if !StorageExists(chainStorage) {
+		return nil, ErrNoBlockchain
+	}
+
+	db, err := OpenStorage(chainStorage, true)
+	if err != nil {
+		return nil, err
+	}
+
+	blockchain := &Blockchain{db: db}
+	err = db.View(func(tx StorageTx) error {
+		b := tx.Bucket([]byte(BlocksBucket))
+		if b == nil {
+			return ErrNoBlockchain
+		}
+		if err := checkGenesis(tx); err != nil {
+			return err
+		}
+		blockchain.l = append([]byte{}, b.Get([]byte("l"))...)
+		blockchain.addrIndex = addrIndexEnabled(tx)
+
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return blockchain, nil
+}
+
 // 새로운 블록체인에 블록연결([제네시스블록]-[새롭게 생성되는 블록]-[...])
 // 6) 영속성으로 인한 변경점
 //   - .blocks에 저장하던 것을 boltDB에 저장할 수 있도록 변경
@@ -116,36 +252,197 @@ func NewBlockchain() *Blockchain {
 //
 // 11) 서명 기능으로 인한 변경점
 //   - 블럭을 추가하기 이전에, 블록에 추가될 거래를 검증
-func (bc *Blockchain) AddBlock(transactions []*Transaction) {
+//
+// 12) 보상 반감기로 인한 변경점
+//   - 코인베이스 트랜잭션이 블록 높이에 따른 보상과 수수료보다 많이 지급하는 경우 블록을 거부
+//
+// 18) 주소별 거래 내역으로 인한 변경점
+//   - 블록을 저장하면서 블록의 트랜잭션들을 주소 색인(addrindex)에 추가
+//
+// 19) 주소 색인이 선택 사항이 되면서 색인이 켜져 있는 경우에만 추가
+//
+// 20) 블록 높이 추가로 인한 변경점
+//   - 블록 높이 색인(heights)에 높이 -> 블록 해시를 추가
+//
+// 22) 채굴 기능으로 인한 변경점
+//   - 추가된 블록을 반환
+//   - 메모리풀의 트랜잭션이 재구성이나 rollback 으로 유효하지 않게 되어도 채굴 주기(mineLoop)가 멈추지 않도록 panic 대신 에러를 반환
+//   - 트랜잭션은 서명뿐 아니라 입력이 소비되지 않았는지까지 검증(ValidateTransaction)하며, 실패하면 그 트랜잭션의 invalidTxError
+//
+// 25) P2P 네트워크로 인한 변경점
+//   - 채굴한 블록을 저장하는 부분을 connectBlock() 으로 분리하여 다른 노드에게 받은 블록(AcceptBlock)도 같은 방식으로 저장
+//
+// 26) 블록 색인과 UTXO 집합으로 인한 변경점
+//   - connectBlock() 에서 블록 색인(blockindex)에 누적 작업량을 기록하고 UTXO 집합(chainstate)을 갱신
+//
+// 31) 쓰기 잠금(writeMu)을 잡고 addBlock() 을 실행
+//
+// 36) 블록의 시간은 현재 시간이 median-time-past 이하라면 median-time-past + 1 (nextBlockTime)
+func (bc *Blockchain) AddBlock(transactions []*Transaction) (*Block, error) {
+	bc.writeMu.Lock()
+	defer bc.writeMu.Unlock()
+
+	return bc.addBlock(transactions)
+}
+
+// 블록에 담을 수 없는 트랜잭션, 채굴 주기에서 메모리풀에서 제거하기 위해 트랜잭션 ID 를 가짐
+type invalidTxError struct {
+	txid []byte
+	err  error
+}
+
+func (e *invalidTxError) Error() string { return e.err.Error() }
+func (e *invalidTxError) Unwrap() error { return e.err }
+
+// 쓰기 잠금을 잡은 메서드(AddBlock, MineBlock, SendRawTransaction)에서 호출
+func (bc *Blockchain) addBlock(transactions []*Transaction) (*Block, error) {
+	spent := make(map[string]bool)
 	for _, tx := range transactions {
-		if isVerified := bc.VerifyTransaction(tx); !isVerified {
-			log.Panic("ERROR: Invalid transaction")
+		if tx.IsCoinbase() {
+			continue
+		}
+		if err := bc.ValidateTransaction(tx); err != nil {
+			return nil, &invalidTxError{tx.ID, err}
+		}
+		for _, in := range tx.Vin {
+			outpoint := Outpoint{in.Txid, in.Vout}.String()
+			if spent[outpoint] {
+				return nil, &invalidTxError{tx.ID, fmt.Errorf("output %s is spent twice in the block", outpoint)}
+			}
+			spent[outpoint] = true
 		}
 	}
+	height := bc.GetBestHeight() + 1
+	if err := bc.ValidateCoinbase(transactions, height); err != nil {
+		return nil, fmt.Errorf("invalid coinbase: %w", err)
+	}
+
+	timestamp, err := bc.nextBlockTime()
+	if err != nil {
+		return nil, err
+	}
+	block := newBlockAt(transactions, bc.l, height, timestamp)
+	if err := bc.connectBlock(block); err != nil {
+		return nil, err
+	}
 
-	block := NewBlock(transactions, bc.l)
-	err := bc.db.Update(func(tx *bolt.Tx) error {
+	return block, nil
+}
+
+// 검증된 블록을 마지막 블록으로 저장하기 위한 메서드
+// 블록과 마지막 블록해시(l), 높이 색인, (켜져 있다면) 주소 색인을 하나의 bolt 트랜잭션으로 저장
+// 27) UTXO 집합에서 지운 출력들을 블록 되돌리기 기록(undo)으로 함께 저장
+// 29) 블록 필터도 함께 저장
+// 31) 마지막 블록해시(l)는 트랜잭션이 끝난 뒤에 바꿈
+func (bc *Blockchain) connectBlock(block *Block) error {
+	err := bc.db.Update(func(tx StorageTx) error {
 		b := tx.Bucket([]byte(BlocksBucket))
 
 		err := b.Put(block.Hash, block.Serialize())
 		if err != nil {
-			fmt.Println("error : ", err.Error())
-			log.Panic(err)
+			return err
 		}
 
 		err = b.Put([]byte("l"), block.Hash)
 		if err != nil {
-			fmt.Println("error : ", err.Error())
-			log.Panic(err)
+			return err
+		}
+
+		err = putBlockHeight(tx, block)
+		if err != nil {
+			return err
+		}
+
+		err = putBlockIndex(tx, block, blockStatusValid)
+		if err != nil {
+			return err
+		}
+
+		undo, err := updateUTXOSet(tx, block)
+		if err != nil {
+			return err
+		}
+
+		err = putBlockUndo(tx, block.Hash, undo)
+		if err != nil {
+			return err
+		}
+
+		err = putBlockFilter(tx, block)
+		if err != nil {
+			return err
+		}
+
+		if bc.addrIndex {
+			err = indexBlockAddresses(tx, block, block.Height)
+			if err != nil {
+				return err
+			}
 		}
-		bc.l = block.Hash
 
 		return nil
 	})
 	if err != nil {
-		fmt.Println("error : ", err.Error())
-		log.Panic(err)
+		return err
+	}
+	bc.setTip(block.Hash)
+
+	return nil
+}
+
+// 26. 마지막 블록을 메인 체인에서 해제하기 위한 메서드
+// 블록과 블록 색인은 다른 갈래의 블록으로 남겨두고, 마지막 블록해시(l), 높이 색인, UTXO 집합, 주소 색인을 이전 블록 기준으로 되돌림
+// 27) 입력이 소비한 출력을 이전 트랜잭션에서 찾지 않고 블록 되돌리기 기록(undo)에서 가져옴
+// 31) 마지막 블록해시(l)는 트랜잭션이 끝난 뒤에 바꿈
+func (bc *Blockchain) disconnectBlock(block *Block) error {
+	if !bytes.Equal(block.Hash, bc.l) {
+		return fmt.Errorf("block %x is not the tip", block.Hash)
+	}
+	if block.Height == 0 {
+		return errors.New("cannot disconnect the genesis block")
+	}
+
+	err := bc.db.Update(func(tx StorageTx) error {
+		undo, err := getBlockUndo(tx, block)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket([]byte(BlocksBucket)).Put([]byte("l"), block.PrevBlockHash)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket([]byte(HeightsBucket)).Delete(heightKey(block.Height))
+		if err != nil {
+			return err
+		}
+
+		err = revertUTXOSet(tx, block, undo.Spent)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket([]byte(UndoBucket)).Delete(block.Hash)
+		if err != nil {
+			return err
+		}
+
+		if bc.addrIndex {
+			err = unindexBlockAddresses(tx, block)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	bc.setTip(block.PrevBlockHash)
+
+	return nil
 }
 
 //================================================================================
@@ -155,11 +452,13 @@ func (bc *Blockchain) AddBlock(transactions []*Transaction) {
 // - 난이도는 16진수를 나타내며 24의 경우 24bit 즉, 끝자리 0이 6개를 의미함
 
 // target 지정을 우선하며 Shift 연산자를 사용하여 target을 지정함
+// 33) 난이도는 네트워크(netParams.TargetBits)에 따름
+// 35) regtest 는 최소 난이도(1)로 해시 두 번 중 한 번꼴로 작업증명을 만족
 func NewProofOfWork(b *Block) *ProofOfWork {
 	target := big.NewInt(1)
-	target.Lsh(target, 256-targetBits)
+	target.Lsh(target, uint(256-netParams.TargetBits))
 
-	pow := &ProofOfWork{b, target}
+	pow := &ProofOfWork{b, target, b.HashTransaction()}
 	return pow
 }
 
@@ -168,13 +467,20 @@ func NewProofOfWork(b *Block) *ProofOfWork {
 // 이때 nonce는 반복을 위한 단순한 counter용도로 사용
 // 8) 트랜잭션 기능으로 인한 변경점
 //   - 작업증명을 위한 준비데이터를 data에서 Block.HashTransaction()을 사용하여 트랜잭션을 해싱
+//
+// 20) 블록 높이 추가로 인한 변경점
+//   - 블록 높이도 작업증명 데이터에 포함
+//
+// 28) 머클 트리로 인한 변경점
+//   - nonce 마다 트랜잭션들을 다시 해싱하지 않고 NewProofOfWork() 에서 구한 머클 루트를 사용
 func (pow *ProofOfWork) prepareData(nonce int64) []byte {
 	data := bytes.Join([][]byte{
 		pow.block.PrevBlockHash,
-		pow.block.HashTransaction(),
+		pow.merkleRoot,
 		IntToHex(pow.block.Timestamp),
+		IntToHex(int64(pow.block.Height)),
 		IntToHex(nonce),
-		IntToHex(targetBits),
+		IntToHex(int64(netParams.TargetBits)),
 	}, []byte{})
 
 	return data
@@ -243,15 +549,16 @@ func DeserializeBlock(d []byte) *Block {
 }
 
 // 블록 조회를 위한 블록체인 내부 순회 반복자 함수
+// 31) 블록은 지워지지 않으므로 생성 시점의 마지막 블록해시부터 순회하면 재구성되어도 같은 블록들을 반환
 func NewBlockchainIterator(bc *Blockchain) *blockchainIterator {
-	return &blockchainIterator{bc.db, bc.l}
+	return &blockchainIterator{bc.db, bc.Tip()}
 }
 
 // BoltDB를 조회하여 버킷(블록)을 반환하며 가장 마지막 블록-> 최초의 블록 순서로 조회
 func (i *blockchainIterator) Next() *Block {
 	var block *Block
 
-	err := i.db.View(func(tx *bolt.Tx) error {
+	err := i.db.View(func(tx StorageTx) error {
 		b := tx.Bucket([]byte(BlocksBucket))
 
 		encodedBlock := b.Get(i.hash)
@@ -282,59 +589,85 @@ func (i *blockchainIterator) HasNext() bool {
 // 블록체인을 새로 생성(제네시스 블록 생성)
 // 8) 트랜잭션 기능 추가로 인한 변경점
 //   - 입력 파라메타 "address string" 추가 : 블록체인을 생성하고 제네시스 블록을 채굴한 사람에게 보상을 지급을 위함
-func CreateBlockchain(address string) *Blockchain {
-	db, err := bolt.Open(dbFile, 0600, nil)
-	if err != nil {
-		log.Panic(err)
-	}
+//
+// 19) 주소 색인으로 인한 변경점
+//   - addrIndex 가 true 이면 주소 색인을 만들고 제네시스 블록부터 색인
+//
+// 26) 블록 색인과 UTXO 집합으로 인한 변경점
+//   - 제네시스 블록을 블록 색인과 UTXO 집합에 추가
+//
+// 32) 이미 블록이 있다면 ErrBlockchainExists 를 반환
+//   - 노드(startnode)가 만든 블록이 없는 빈 블록체인에는 제네시스 블록을 추가
+//
+// 33) 제네시스 블록의 시간과 코인베이스 데이터는 네트워크(netParams)에 따름
+//
+// 34) 제네시스 블록을 채굴하지 않고 네트워크의 고정된 제네시스 블록(GenesisBlock)을 저장
+//   - 보상을 받을 주소(address)를 받지 않음, regtest 의 premine 은 cli 에서 다음 블록을 채굴
+func CreateBlockchain(addrIndex bool) (*Blockchain, error) {
+	db := openChainStorage()
 
 	var l []byte
 
-	err = db.Update(func(tx *bolt.Tx) error {
-		b, err := tx.CreateBucket([]byte(BlocksBucket))
+	err := db.Update(func(tx StorageTx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(BlocksBucket))
 		if err != nil {
 			log.Panic(err)
 		}
-
-		//genesis := NewBlock("Genesis Block", []byte{})
-		genesis := NewBlock([]*Transaction{NewCoinbaseTX("", address)}, []byte{})
-
-		err = b.Put(genesis.Hash, genesis.Serialize())
-		if err != nil {
-			log.Panic(err)
+		if len(b.Get([]byte("l"))) > 0 {
+			return ErrBlockchainExists
 		}
 
-		// "l" 키는 마지막 블록해시를 저장합니다.
-		err = b.Put([]byte("l"), genesis.Hash)
-		if err != nil {
+		genesis := GenesisBlock()
+		if err := putGenesisBlock(tx, genesis, addrIndex); err != nil {
 			log.Panic(err)
 		}
-
 		l = genesis.Hash
 
 		return nil
 	})
+	if err == ErrBlockchainExists {
+		db.Close()
+		return nil, err
+	}
 	if err != nil {
 		log.Panic(err)
 	}
 
-	return &Blockchain{db, l}
+	return &Blockchain{db: db, l: l, addrIndex: addrIndex}, nil
 }
 
 // BlockchainIterator 를 사용하여 블록체인을 순회
-func (bc *Blockchain) List() {
-	bci := NewBlockchainIterator(bc)
-
-	for bci.HasNext() {
-		block := bci.Next()
+// 21) printchain 명령으로 인한 변경점
+//   - 트랜잭션을 %s 로 출력하던 것을 BlockView 로 변환하여 높이, 입력(참조 출력, 서명한 주소), 출력(금액, 받는 주소)을 출력
+//   - limit 개의 블록만 출력(0 이하면 전부), reverse 이면 제네시스 블록부터 출력, asJSON 이면 json 으로 출력
+func (bc *Blockchain) List(w io.Writer, limit int, reverse, asJSON bool) {
+	var views []BlockView
+	var next func() *Block
+	var hasNext func() bool
+
+	if reverse {
+		fi := NewBlockchainForwardIterator(bc)
+		next, hasNext = fi.Next, fi.HasNext
+	} else {
+		bci := NewBlockchainIterator(bc)
+		next, hasNext = bci.Next, bci.HasNext
+	}
 
-		fmt.Printf("PrevBlockHash: %x\n", block.PrevBlockHash)
-		fmt.Printf("Hash: %x\n", block.Hash)
-		fmt.Printf("Data: %s\n", block.Transactions)
+	for hasNext() && (limit <= 0 || len(views) < limit) {
+		views = append(views, NewBlockView(next()))
+	}
 
-		pow := NewProofOfWork(block)
-		fmt.Println("pow:", pow.Validate(block))
+	if asJSON {
+		result, err := json.MarshalIndent(views, "", "  ")
+		if err != nil {
+			log.Panic(err)
+		}
+		fmt.Fprintln(w, string(result))
+		return
+	}
 
-		fmt.Println()
+	for _, view := range views {
+		view.Print(w)
+		fmt.Fprintln(w)
 	}
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"testing"
+)
+
+// regtest 네트워크의 블록체인을 메모리 저장소에 만들기 위한 테스트 함수, 테스트가 끝나면 지움
+func newTestBlockchain(t *testing.T) *Blockchain {
+	t.Helper()
+
+	params, backend, dir := netParams, storageBackend, dataDir
+	netParams, storageBackend, dataDir = &RegTestParams, storageMemory, t.TempDir()
+
+	bc, err := CreateBlockchain(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		bc.db.Close()
+		RemoveStorage(chainStorage)
+		SetMockTime(0)
+		netParams, storageBackend, dataDir = params, backend, dir
+	})
+
+	return bc
+}
+
+// 보상을 받을 새 지갑의 주소
+func newTestAddress() string {
+	return string(NewWallet().GetAddress())
+}
+
+// 지갑의 개인키로 서명하기 위한 테스트 함수, 공개키 해시와 무관하게 항상 wallet 의 개인키를 반환
+func testKeyLookup(wallet *Wallet) KeyLookup {
+	return func([]byte) (*ecdsa.PrivateKey, error) { return wallet.PrivKey, nil }
+}
+
+// wallet 의 출력으로 to 에게 amount 를 보내는 서명된 트랜잭션을 만들기 위한 테스트 함수
+// signer 의 공개키를 입력에 넣고 signer 의 개인키로 서명하므로, signer 가 wallet 이 아니라면 다른 사람의 출력을 사용하는 트랜잭션
+func newTestTransaction(t *testing.T, bc *Blockchain, wallet, signer *Wallet, to string, amount uint64) *Transaction {
+	t.Helper()
+
+	from := wallet.GetAddress()
+	tx, err := bc.CreateTransaction([]string{from}, map[string][]byte{from: signer.PubKey}, []Recipient{{to, amount}}, "", LargestFirst{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.SignTransaction(testKeyLookup(signer), tx); err != nil {
+		t.Fatal(err)
+	}
+
+	return tx
+}
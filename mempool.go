@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// 22. 메모리풀 추가
+// 지금까지는 거래를 만들면 바로 블록을 채굴하였기 때문에 대기 중인 트랜잭션이 없었음
+// RPC 서버처럼 계속 실행되는 프로세스에서는 검증된 트랜잭션을 메모리풀에 모아두고, 채굴할 때 한 블록에 담음
+
+func NewMempool() *Mempool {
+	return &Mempool{txs: make(map[string]*Transaction), spent: make(map[string]string)}
+}
+
+// 트랜잭션을 검증하여 메모리풀에 추가하기 위한 메서드
+// 블록체인 기준의 검증(ValidateTransaction)에 더해 메모리풀의 다른 트랜잭션과 같은 출력을 사용하는지 확인
+// 메모리풀은 트랜잭션 ID 를 키로 사용하므로, ID 가 내용의 해시와 다른 트랜잭션은 먼저 거부
+func (mp *Mempool) Add(bc *Blockchain, tx *Transaction) error {
+	if !bytes.Equal(tx.ID, tx.Hash()) {
+		return fmt.Errorf("transaction %x has an invalid id", tx.ID)
+	}
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	txID := hex.EncodeToString(tx.ID)
+	if mp.txs[txID] != nil {
+		return fmt.Errorf("transaction %s is already in the mempool", txID)
+	}
+	for _, in := range tx.Vin {
+		if other, ok := mp.spent[Outpoint{in.Txid, in.Vout}.String()]; ok {
+			return fmt.Errorf("transaction %s conflicts with %s in the mempool", txID, other)
+		}
+	}
+	if err := bc.ValidateTransaction(tx); err != nil {
+		return err
+	}
+
+	mp.add(tx)
+
+	return nil
+}
+
+func (mp *Mempool) add(tx *Transaction) {
+	txID := hex.EncodeToString(tx.ID)
+
+	mp.txs[txID] = tx
+	mp.order = append(mp.order, txID)
+	for _, in := range tx.Vin {
+		mp.spent[Outpoint{in.Txid, in.Vout}.String()] = txID
+	}
+}
+
+// 트랜잭션 ID 로 메모리풀의 트랜잭션을 찾기 위한 메서드
+func (mp *Mempool) Get(txid []byte) *Transaction {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	return mp.txs[hex.EncodeToString(txid)]
+}
+
+// 메모리풀의 트랜잭션들을 들어온 순서대로 반환
+func (mp *Mempool) Transactions() []*Transaction {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	var txs []*Transaction
+	for _, txID := range mp.order {
+		txs = append(txs, mp.txs[txID])
+	}
+
+	return txs
+}
+
+// 메모리풀의 트랜잭션들이 사용한 출력(txid:idx)들, 새 거래를 만들 때 제외하기 위해 사용
+func (mp *Mempool) SpentOutpoints() map[string]bool {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	spent := make(map[string]bool)
+	for outpoint := range mp.spent {
+		spent[outpoint] = true
+	}
+
+	return spent
+}
+
+// 채굴할 수 없는 트랜잭션을 메모리풀에서 제거하기 위한 메서드
+func (mp *Mempool) Remove(txid []byte) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	txID := hex.EncodeToString(txid)
+	tx := mp.txs[txID]
+	if tx == nil {
+		return
+	}
+
+	delete(mp.txs, txID)
+	for i, id := range mp.order {
+		if id == txID {
+			mp.order = append(mp.order[:i], mp.order[i+1:]...)
+			break
+		}
+	}
+	for _, in := range tx.Vin {
+		delete(mp.spent, Outpoint{in.Txid, in.Vout}.String())
+	}
+}
+
+// 채굴에 실패한 에러가 트랜잭션 때문이라면 그 트랜잭션을 제거하고 남은 트랜잭션들을 다시 검증
+func (mp *Mempool) Evict(bc *Blockchain, err error) {
+	var txErr *invalidTxError
+	if errors.As(err, &txErr) {
+		mp.Remove(txErr.txid)
+	}
+	mp.Update(bc)
+}
+
+// 블록이 추가된 뒤 메모리풀을 정리하기 위한 메서드
+// 남은 트랜잭션들을 블록체인 기준으로 다시 검증하여, 블록에 담겼거나 더 이상 유효하지 않은 트랜잭션을 제거
+func (mp *Mempool) Update(bc *Blockchain) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	txs := mp.txs
+	order := mp.order
+	mp.txs = make(map[string]*Transaction)
+	mp.order = nil
+	mp.spent = make(map[string]string)
+
+	for _, txID := range order {
+		if bc.ValidateTransaction(txs[txID]) == nil {
+			mp.add(txs[txID])
+		}
+	}
+}
+
+// 27. 메인 체인에서 해제된 블록들의 트랜잭션을 메모리풀로 되돌리기 위한 메서드
+// disconnected 는 마지막 블록부터의 순서이므로 오래된 블록의 트랜잭션부터 추가하며, 코인베이스와 더 이상 유효하지 않은 트랜잭션은 제외
+func (mp *Mempool) AddDisconnected(bc *Blockchain, disconnected []*Block) {
+	for i := len(disconnected) - 1; i >= 0; i-- {
+		for _, tx := range disconnected[i].Transactions {
+			if !tx.IsCoinbase() {
+				mp.Add(bc, tx)
+			}
+		}
+	}
+	mp.Update(bc)
+}
+
+// 메모리풀의 현재 상태를 구하기 위한 메서드
+func (mp *Mempool) Info() MempoolInfo {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	info := MempoolInfo{Size: len(mp.txs), Txids: []string{}}
+	for _, txID := range mp.order {
+		info.Bytes += len(mp.txs[txID].Serialize())
+		info.Txids = append(info.Txids, txID)
+	}
+
+	return info
+}
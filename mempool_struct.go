@@ -0,0 +1,19 @@
+package main
+
+import "sync"
+
+// 아직 블록에 담기지 않은 검증된 트랜잭션들을 보관하기 위한 메모리풀
+// txs 의 키는 트랜잭션 ID(hex), spent 는 메모리풀의 트랜잭션들이 사용한 출력(txid:idx) -> 사용한 트랜잭션 ID
+type Mempool struct {
+	mu    sync.Mutex
+	txs   map[string]*Transaction
+	order []string
+	spent map[string]string
+}
+
+// 메모리풀의 현재 상태
+type MempoolInfo struct {
+	Size  int      `json:"size"`
+	Bytes int      `json:"bytes"`
+	Txids []string `json:"txids"`
+}
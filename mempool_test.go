@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// 메모리풀은 검증된 트랜잭션만 받고, 이미 있거나 같은 출력을 사용하거나 ID 가 위조된 트랜잭션은 거부
+func TestMempoolAdd(t *testing.T) {
+	bc := newTestBlockchain(t)
+	tx1, tx2 := newConflictingTransactions(t, bc)
+	mempool := NewMempool()
+
+	if err := mempool.Add(bc, tx1); err != nil {
+		t.Fatal(err)
+	}
+
+	forged := *tx2
+	forged.ID = tx1.ID
+	unsigned := *tx2
+	unsigned.Vin = []TXInput{{tx2.Vin[0].Txid, tx2.Vin[0].Vout, nil, tx2.Vin[0].PubKey}}
+
+	tests := []struct {
+		name string
+		tx   *Transaction
+	}{
+		{"duplicate", tx1},
+		{"conflict", tx2},
+		{"forged id", &forged},
+		{"unsigned", &unsigned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := mempool.Add(bc, tt.tx); err == nil {
+				t.Fatal("added an invalid transaction")
+			}
+			info := mempool.Info()
+			if info.Size != 1 || info.Txids[0] != hex.EncodeToString(tx1.ID) {
+				t.Fatalf("mempool = %v, want only %x", info.Txids, tx1.ID)
+			}
+		})
+	}
+}
+
+// 블록에 담긴 트랜잭션은 Update 로 제거되고, 제거된 트랜잭션이 사용한 출력은 다시 사용할 수 있음
+func TestMempoolUpdateAndRemove(t *testing.T) {
+	bc := newTestBlockchain(t)
+	tx1, tx2 := newConflictingTransactions(t, bc)
+	mempool := NewMempool()
+
+	if err := mempool.Add(bc, tx1); err != nil {
+		t.Fatal(err)
+	}
+	mempool.Remove(tx1.ID)
+	if mempool.Get(tx1.ID) != nil || len(mempool.SpentOutpoints()) != 0 || len(mempool.Transactions()) != 0 {
+		t.Fatal("removed transaction is still in the mempool")
+	}
+
+	if err := mempool.Add(bc, tx2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bc.MineBlock(mempool.Transactions(), newTestAddress()); err != nil {
+		t.Fatal(err)
+	}
+	mempool.Update(bc)
+	if info := mempool.Info(); info.Size != 0 {
+		t.Fatalf("mempool has %d transactions after mining, want 0", info.Size)
+	}
+}
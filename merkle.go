@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+)
+
+// 28. 머클 트리(Merkle tree)
+// 트랜잭션 ID 들을 잎(leaf)으로 하여 두 개씩 이어 붙여 해싱하는 것을 하나가 남을 때까지 반복, 남은 하나가 머클 루트
+// 어떤 높이의 해시 개수가 홀수라면 마지막 해시를 한 번 더 사용
+// 트랜잭션 하나의 머클 증명은 각 높이에서 짝이 되는 해시들이므로 트랜잭션 수가 n 일 때 log2(n) 개의 해시로 증명 가능
+func merkleParent(left, right []byte) []byte {
+	hash := sha256.Sum256(bytes.Join([][]byte{left, right}, []byte{}))
+
+	return hash[:]
+}
+
+// 해시들의 머클 루트를 구하기 위한 함수, 해시가 없다면 빈 데이터의 해시
+func MerkleRoot(hashes [][]byte) []byte {
+	if len(hashes) == 0 {
+		hash := sha256.Sum256([]byte{})
+		return hash[:]
+	}
+
+	level := hashes
+	for len(level) > 1 {
+		level = merkleLevel(level)
+	}
+
+	return level[0]
+}
+
+// 머클 트리의 한 높이에서 바로 위 높이의 해시들을 구하기 위한 함수
+func merkleLevel(level [][]byte) [][]byte {
+	var next [][]byte
+	for i := 0; i < len(level); i += 2 {
+		right := level[i]
+		if i+1 < len(level) {
+			right = level[i+1]
+		}
+		next = append(next, merkleParent(level[i], right))
+	}
+
+	return next
+}
+
+// 마지막 해시를 한 번 더 사용하기 때문에 [a, b, c] 와 [a, b, c, c] 처럼 다른 트랜잭션 목록이 같은 머클 루트(블록 해시)를 가질 수 있음(CVE-2012-2459)
+// 변형된 블록이 먼저 저장되면 같은 해시의 원래 블록을 받을 수 없으므로, 저장하기 전에
+// 같은 트랜잭션 ID 가 있거나 어떤 높이에서 짝이 되는 두 해시가 같은 블록을 거부
+func CheckMerkleMutation(block *Block) error {
+	seen := make(map[string]bool)
+	var level [][]byte
+	for _, tx := range block.Transactions {
+		id := hex.EncodeToString(tx.ID)
+		if seen[id] {
+			return fmt.Errorf("block %x has duplicate transaction %s", block.Hash, id)
+		}
+		seen[id] = true
+		level = append(level, tx.ID)
+	}
+
+	for ; len(level) > 1; level = merkleLevel(level) {
+		for i := 0; i+1 < len(level); i += 2 {
+			if bytes.Equal(level[i], level[i+1]) {
+				return fmt.Errorf("block %x has a mutated merkle tree", block.Hash)
+			}
+		}
+	}
+
+	return nil
+}
+
+// 블록 안의 트랜잭션 하나에 대한 머클 증명을 만들기 위한 함수
+func NewMerkleProof(block *Block, txid []byte) (*MerkleProof, error) {
+	var level [][]byte
+	index := -1
+	for i, tx := range block.Transactions {
+		if bytes.Equal(tx.ID, txid) {
+			index = i
+		}
+		level = append(level, tx.ID)
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("transaction %x is not in block %x", txid, block.Hash)
+	}
+
+	proof := &MerkleProof{Index: index}
+	for i := index; len(level) > 1; i /= 2 {
+		sibling := i ^ 1
+		if sibling >= len(level) {
+			sibling = i
+		}
+		proof.Hashes = append(proof.Hashes, level[sibling])
+		level = merkleLevel(level)
+	}
+
+	return proof, nil
+}
+
+// 머클 증명으로 트랜잭션 ID 에서 머클 루트를 계산하기 위한 메서드
+// 결과가 블록 헤더의 MerkleRoot 와 같다면 트랜잭션이 그 블록에 포함된 것
+func (p *MerkleProof) Root(txid []byte) []byte {
+	hash := txid
+	index := p.Index
+	for _, sibling := range p.Hashes {
+		if index%2 == 0 {
+			hash = merkleParent(hash, sibling)
+		} else {
+			hash = merkleParent(sibling, hash)
+		}
+		index /= 2
+	}
+
+	return hash
+}
+
+// 블록에서 트랜잭션들을 뺀 블록 헤더를 구하기 위한 메서드
+func (b *Block) Header() BlockHeader {
+	return BlockHeader{b.PrevBlockHash, b.Hash, b.HashTransaction(), b.Timestamp, b.Nonce, b.Height}
+}
+
+// 블록 헤더의 작업증명을 위한 함수
+// 트랜잭션이 없는 블록으로 만들고 머클 루트는 헤더의 것을 사용
+func NewHeaderProofOfWork(h *BlockHeader) *ProofOfWork {
+	pow := NewProofOfWork(&Block{h.PrevBlockHash, h.Hash, h.Timestamp, nil, h.Nonce, h.Height})
+	pow.merkleRoot = h.MerkleRoot
+
+	return pow
+}
+
+// 블록 헤더의 해시가 작업증명 데이터의 해시와 같고 난이도(target)를 만족하는지 검사하기 위한 함수
+func CheckHeaderProofOfWork(h *BlockHeader) error {
+	pow := NewHeaderProofOfWork(h)
+	hash := sha256.Sum256(pow.prepareData(h.Nonce))
+	if !bytes.Equal(hash[:], h.Hash) {
+		return fmt.Errorf("block hash %x does not match its header", h.Hash)
+	}
+	if !pow.Validate(pow.block) {
+		return fmt.Errorf("block %x does not satisfy the proof of work", h.Hash)
+	}
+
+	return nil
+}
+
+// 블록 헤더 하나의 작업량
+func headerWork(h *BlockHeader) *big.Int {
+	return targetWork(NewHeaderProofOfWork(h).target)
+}
+
+// height 다음 블록부터 최대 max 개의 블록 헤더를 구하기 위한 메서드
+func (bc *Blockchain) HeadersAfter(height, max int) ([]BlockHeader, error) {
+	var headers []BlockHeader
+
+	for _, hash := range bc.BlockHashesAfter(height, max) {
+		block, err := bc.GetBlock(hash)
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, block.Header())
+	}
+
+	return headers, nil
+}
+
+// 공개키 해시로 잠긴 UTXO 들이 속한 트랜잭션과 그 머클 증명을 구하기 위한 메서드
+// 마지막 블록부터 순회하며 UTXO 가 속한 트랜잭션들을 모두 찾으면 멈춤
+func (bc *Blockchain) FindTxProofs(pubKeyHash []byte) ([]TxProof, error) {
+	var proofs []TxProof
+	vouts := make(map[string][]int)
+	for _, u := range bc.FindSpendableOutputs(pubKeyHash) {
+		txID := hex.EncodeToString(u.Txid)
+		vouts[txID] = append(vouts[txID], u.Vout)
+	}
+
+	bci := NewBlockchainIterator(bc)
+	for len(proofs) < len(vouts) && bci.HasNext() {
+		block := bci.Next()
+		for _, tx := range block.Transactions {
+			vout, ok := vouts[hex.EncodeToString(tx.ID)]
+			if !ok {
+				continue
+			}
+			proof, err := NewMerkleProof(block, tx.ID)
+			if err != nil {
+				return nil, err
+			}
+			proofs = append(proofs, TxProof{tx, block.Hash, *proof, vout})
+		}
+	}
+
+	return proofs, nil
+}
@@ -0,0 +1,28 @@
+package main
+
+// 28. 블록 헤더
+// 블록에서 트랜잭션들을 머클 루트(MerkleRoot)로 대신한 것으로, 작업증명과 이전 블록과의 연결을 검증하는 데 필요한 값만 가짐
+type BlockHeader struct {
+	PrevBlockHash []byte
+	Hash          []byte
+	MerkleRoot    []byte
+	Timestamp     int64
+	Nonce         int64
+	Height        int
+}
+
+// 트랜잭션이 블록에 포함되었음을 증명하기 위한 머클 증명
+// Index 는 블록 안에서의 트랜잭션 위치, Hashes 는 잎(leaf)부터 루트 방향으로 각 높이에서 짝이 되는 해시
+type MerkleProof struct {
+	Index  int
+	Hashes [][]byte
+}
+
+// 전체 노드가 경량 클라이언트에게 보내는 트랜잭션과 그 머클 증명
+// Vout 은 요청한 공개키 해시로 잠겨 있고 아직 소비되지 않은 출력의 인덱스
+type TxProof struct {
+	Tx        *Transaction
+	BlockHash []byte
+	Proof     MerkleProof
+	Vout      []int
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMerkleRootOddLevel(t *testing.T) {
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+
+	if !bytes.Equal(MerkleRoot([][]byte{a, b, c}), MerkleRoot([][]byte{a, b, c, c})) {
+		t.Fatal("expected [a, b, c] and [a, b, c, c] to share a merkle root")
+	}
+}
+
+func TestCheckMerkleMutation(t *testing.T) {
+	txs := func(ids ...string) []*Transaction {
+		var txs []*Transaction
+		for _, id := range ids {
+			txs = append(txs, &Transaction{ID: []byte(id)})
+		}
+		return txs
+	}
+
+	tests := []struct {
+		name    string
+		ids     []string
+		mutated bool
+	}{
+		{"single", []string{"a"}, false},
+		{"odd", []string{"a", "b", "c"}, false},
+		{"even", []string{"a", "b", "c", "d"}, false},
+		{"duplicated last", []string{"a", "b", "c", "c"}, true},
+		{"duplicate txid", []string{"a", "b", "a"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckMerkleMutation(&Block{Transactions: txs(tt.ids...)})
+			if (err != nil) != tt.mutated {
+				t.Fatalf("CheckMerkleMutation() = %v, mutated %v", err, tt.mutated)
+			}
+		})
+	}
+}
+
+// 변형된 다른 갈래의 블록을 먼저 받아도 같은 해시의 원래 블록을 받을 수 있어야 함
+func TestAcceptBlockRejectsMutatedBlock(t *testing.T) {
+	bc := newTestBlockchain(t)
+	address := newTestAddress()
+	genesis := bc.Tip()
+	if _, err := bc.Generate(2, address); err != nil {
+		t.Fatal(err)
+	}
+
+	txs := []*Transaction{
+		NewCoinbaseTX("", address, 1, 0),
+		NewCoinbaseTX("b", address, 1, 0),
+		NewCoinbaseTX("c", address, 1, 0),
+	}
+	block := NewBlock(txs, genesis, 1)
+	mutated := *block
+	mutated.Transactions = append(append([]*Transaction{}, txs...), txs[2])
+	if !bytes.Equal(mutated.HashTransaction(), block.HashTransaction()) {
+		t.Fatal("expected the mutated block to keep the merkle root")
+	}
+
+	if _, err := bc.AcceptBlock(&mutated); err == nil {
+		t.Fatal("mutated block was accepted")
+	}
+	if _, err := bc.AcceptBlock(block); err != nil {
+		t.Fatalf("block after its mutated copy: %v", err)
+	}
+	if index := bc.GetBlockIndex(block.Hash); index == nil || index.Status != blockStatusHeader {
+		t.Fatalf("block index = %+v, want a side block", index)
+	}
+}
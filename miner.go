@@ -0,0 +1,28 @@
+package main
+
+// 22. 채굴 기능 추가
+// 트랜잭션들을 담은 블록을 채굴하고, minerAddress 가 있다면 블록 보상과 수수료를 지급하는 코인베이스 트랜잭션을 첫 번째로 넣음
+// minerAddress 가 없다면 기존 send 와 같이 보상 없이 채굴
+// 31) 높이와 수수료를 구하는 동안 다른 블록이 추가되지 않도록 쓰기 잠금을 잡고 실행
+// 수수료를 구할 수 없는 트랜잭션이 있다면 panic 대신 그 트랜잭션의 invalidTxError 를 반환
+func (bc *Blockchain) MineBlock(transactions []*Transaction, minerAddress string) (*Block, error) {
+	bc.writeMu.Lock()
+	defer bc.writeMu.Unlock()
+
+	if minerAddress == "" {
+		return bc.addBlock(transactions)
+	}
+
+	var fees uint64
+	for _, tx := range transactions {
+		fee, err := bc.TransactionFee(tx)
+		if err != nil {
+			return nil, &invalidTxError{tx.ID, err}
+		}
+		fees += fee
+	}
+
+	coinbase := NewCoinbaseTX("", minerAddress, bc.GetBestHeight()+1, fees)
+
+	return bc.addBlock(append([]*Transaction{coinbase}, transactions...))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// 같은 출력을 사용하는 트랜잭션 두 개를 만들기 위한 테스트 함수
+func newConflictingTransactions(t *testing.T, bc *Blockchain) (*Transaction, *Transaction) {
+	t.Helper()
+
+	wallet, to := NewWallet(), newTestAddress()
+	if _, err := bc.Generate(1, wallet.GetAddress()); err != nil {
+		t.Fatal(err)
+	}
+
+	return newTestTransaction(t, bc, wallet, wallet, to, 1), newTestTransaction(t, bc, wallet, wallet, to, 2)
+}
+
+// 유효하지 않은 트랜잭션으로 채굴하면 panic 대신 그 트랜잭션의 에러를 반환하고 블록을 추가하지 않음
+func TestMineBlockInvalidTransaction(t *testing.T) {
+	bc := newTestBlockchain(t)
+	tx1, tx2 := newConflictingTransactions(t, bc)
+	miner := newTestAddress()
+
+	checkInvalid := func(t *testing.T, txs []*Transaction, bad *Transaction) {
+		t.Helper()
+		height := bc.GetBestHeight()
+
+		_, err := bc.MineBlock(txs, miner)
+		var txErr *invalidTxError
+		if !errors.As(err, &txErr) {
+			t.Fatalf("err = %v, want invalidTxError", err)
+		}
+		if !bytes.Equal(txErr.txid, bad.ID) {
+			t.Fatalf("txid = %x, want %x", txErr.txid, bad.ID)
+		}
+		if got := bc.GetBestHeight(); got != height {
+			t.Fatalf("height = %d, want %d", got, height)
+		}
+	}
+
+	t.Run("double spend in block", func(t *testing.T) {
+		checkInvalid(t, []*Transaction{tx1, tx2}, tx2)
+	})
+	t.Run("spent output", func(t *testing.T) {
+		if _, err := bc.AddBlock([]*Transaction{tx1}); err != nil {
+			t.Fatal(err)
+		}
+		checkInvalid(t, []*Transaction{tx2}, tx2)
+	})
+}
+
+// 채굴에 실패한 트랜잭션은 메모리풀에서 제거되고 다음 채굴은 성공함
+func TestMempoolEvict(t *testing.T) {
+	bc := newTestBlockchain(t)
+	tx1, tx2 := newConflictingTransactions(t, bc)
+	miner := newTestAddress()
+
+	mempool := NewMempool()
+	if err := mempool.Add(bc, tx2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bc.AddBlock([]*Transaction{tx1}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := bc.MineBlock(mempool.Transactions(), miner)
+	if err == nil {
+		t.Fatal("mined a block with a spent output")
+	}
+	mempool.Evict(bc, err)
+	if mempool.Get(tx2.ID) != nil || len(mempool.SpentOutpoints()) != 0 {
+		t.Fatalf("transaction %x is still in the mempool", tx2.ID)
+	}
+
+	if _, err := bc.MineBlock(mempool.Transactions(), miner); err != nil {
+		t.Fatal(err)
+	}
+}
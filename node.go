@@ -0,0 +1,631 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"time"
+)
+
+// 25. P2P 네트워크
+// 블록체인은 로컬의 chain.db 에만 있었고 네트워크 기능이 없었음
+// 노드끼리 TCP 로 연결하여 json 메시지를 주고받으며 가장 긴 체인을 동기화하고 새 트랜잭션과 채굴된 블록을 전파
+//   - version/verack : 연결 직후 프로토콜 버전, 마지막 블록 높이, 누적 작업량, 접속 주소를 교환
+//   - getblocks      : locator 이후의 블록 해시 목록(inv)을 요청
+//   - inv            : 가지고 있는 블록 또는 트랜잭션의 해시 목록, 없는 것은 getdata 로 요청
+//   - getdata        : inv 와 같은 형식으로 블록 또는 트랜잭션들을 요청
+//   - block, tx      : 블록 또는 트랜잭션
+//
+// 28. 경량 클라이언트를 위한 메시지
+//   - getheaders/headers : locator 이후의 블록 헤더 목록
+//   - getproofs/proofs   : 공개키 해시로 잠긴 UTXO 가 속한 트랜잭션과 그 머클 증명
+const (
+	protocolVersion   = 2
+	maxInvBlocks      = 500
+	maxHeaders        = 2000
+	maxMessageSize    = 32 << 20
+	reconnectInterval = 5 * time.Second
+	sendTimeout       = 10 * time.Second
+
+	invTypeBlock = "block"
+	invTypeTx    = "tx"
+)
+
+func NewNode(bc *Blockchain, addr string, seeds []string, minerAddress string) *Node {
+	return &Node{bc: bc, mempool: NewMempool(), addr: addr, seeds: seeds, minerAddress: minerAddress, peers: make(map[*peer]bool), quit: make(chan struct{})}
+}
+
+// 노드를 시작하기 위한 메서드
+// addr 에서 연결을 받고, seeds 로 지정된 노드들에 연결(끊어지면 다시 연결)하며, minerAddress 가 있다면 mineInterval 마다 채굴
+// Close 로 멈추면 nil 을 반환
+func (n *Node) Start(mineInterval time.Duration) error {
+	ln, err := net.Listen("tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	n.peersMu.Lock()
+	if n.closed {
+		n.peersMu.Unlock()
+		return nil
+	}
+	n.ln = ln
+	n.peersMu.Unlock()
+
+	n.mu.RLock()
+	log.Printf("Node listening on %s, best height %d", n.addr, n.bc.GetBestHeight())
+	n.mu.RUnlock()
+
+	go n.connectLoop()
+	if n.minerAddress != "" && mineInterval > 0 {
+		go n.mineLoop(mineInterval)
+	}
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case <-n.quit:
+				return nil
+			default:
+				return err
+			}
+		}
+		n.goHandleConn(conn, false, "")
+	}
+}
+
+// 노드를 멈추기 위한 메서드
+// 연결을 받지 않고 모든 연결을 닫은 뒤, 연결을 처리하는 고루틴들이 끝날 때까지 기다림
+func (n *Node) Close() {
+	n.peersMu.Lock()
+	if n.closed {
+		n.peersMu.Unlock()
+		return
+	}
+	n.closed = true
+	close(n.quit)
+	if n.ln != nil {
+		n.ln.Close()
+	}
+	for p := range n.peers {
+		p.conn.Close()
+	}
+	n.peersMu.Unlock()
+
+	n.conns.Wait()
+}
+
+// 연결을 처리하는 고루틴을 시작하기 위한 메서드, 노드가 멈췄다면 연결을 닫음
+func (n *Node) goHandleConn(conn net.Conn, outbound bool, addr string) {
+	n.peersMu.Lock()
+	defer n.peersMu.Unlock()
+
+	if n.closed {
+		conn.Close()
+		return
+	}
+	n.conns.Add(1)
+	go func() {
+		defer n.conns.Done()
+		n.handleConn(conn, outbound, addr)
+	}()
+}
+
+// RPC 서버를 노드와 함께 실행하기 위한 메서드
+// 블록체인, 메모리풀, mu 를 공유하며 RPC 로 받은 트랜잭션은 다른 노드에게 전파
+func (n *Node) RPCServer(user, password string) *RPCServer {
+	return &RPCServer{bc: n.bc, mempool: n.mempool, user: user, password: password, mu: &n.mu, relay: n.relayTx}
+}
+
+// seeds 중 연결되지 않은 노드에 주기적으로 연결
+func (n *Node) connectLoop() {
+	for {
+		for _, seed := range n.seeds {
+			if n.connected(seed) {
+				continue
+			}
+			conn, err := net.DialTimeout("tcp", seed, reconnectInterval)
+			if err != nil {
+				continue
+			}
+			n.goHandleConn(conn, true, seed)
+		}
+
+		select {
+		case <-n.quit:
+			return
+		case <-time.After(reconnectInterval):
+		}
+	}
+}
+
+// 메모리풀에 트랜잭션이 있으면 주기적으로 채굴하고 블록을 전파
+// 34) 제네시스 블록의 보상은 사용할 수 없으므로 메모리풀이 비어 있어도 코인베이스만 담은 블록을 채굴하여 minerAddress 에게 보상을 지급
+func (n *Node) mineLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.quit:
+			return
+		case <-ticker.C:
+		}
+
+		txs := n.mempool.Transactions()
+
+		n.mu.Lock()
+		if len(n.bc.Tip()) == 0 {
+			n.mu.Unlock()
+			continue
+		}
+		block, err := n.bc.MineBlock(txs, n.minerAddress)
+		if err != nil {
+			n.mempool.Evict(n.bc, err)
+			n.mu.Unlock()
+			log.Printf("Mining failed: %v", err)
+			continue
+		}
+		n.mempool.Update(n.bc)
+		n.mu.Unlock()
+
+		log.Printf("Mined block %d %x with %d transactions", block.Height, block.Hash, len(txs))
+		n.broadcast("inv", invMsg{invTypeBlock, [][]byte{block.Hash}}, nil)
+	}
+}
+
+// 연결 하나를 처리하기 위한 메서드, 연결이 끊어지거나 잘못된 메시지를 받으면 연결을 닫음
+// 먼저 연결한 쪽(outbound)이 version 을 보냄
+func (n *Node) handleConn(conn net.Conn, outbound bool, addr string) {
+	p := &peer{conn: conn, enc: json.NewEncoder(conn), addr: addr, outbound: outbound}
+	n.addPeer(p)
+	defer n.removePeer(p)
+
+	if outbound {
+		if err := n.sendVersion(p); err != nil {
+			log.Printf("peer %s: %v", p, err)
+			return
+		}
+	}
+
+	// 39) 메시지 하나를 읽을 때마다 maxMessageSize 바이트까지만 읽도록 제한하여 끝나지 않는 메시지로 메모리를 소모시키지 못하게 함
+	r := io.LimitReader(conn, maxMessageSize).(*io.LimitedReader)
+	dec := json.NewDecoder(r)
+	for {
+		r.N = maxMessageSize
+		var msg message
+		if err := dec.Decode(&msg); err != nil {
+			if r.N == 0 {
+				log.Printf("peer %s: message exceeds %d bytes", p, maxMessageSize)
+			} else if err != io.EOF {
+				log.Printf("peer %s: %v", p, err)
+			}
+			return
+		}
+		if err := n.handleMessage(p, msg); err != nil {
+			log.Printf("peer %s: %v", p, err)
+			return
+		}
+	}
+}
+
+func (n *Node) handleMessage(p *peer, msg message) error {
+	if msg.Command == "version" {
+		return n.handleVersion(p, msg.Payload)
+	}
+	if !p.version {
+		return fmt.Errorf("expected version, got %s", msg.Command)
+	}
+
+	switch msg.Command {
+	case "verack":
+		return nil
+	case "getblocks":
+		return n.handleGetBlocks(p, msg.Payload)
+	case "inv":
+		return n.handleInv(p, msg.Payload)
+	case "getdata":
+		return n.handleGetData(p, msg.Payload)
+	case "block":
+		return n.handleBlock(p, msg.Payload)
+	case "tx":
+		return n.handleTx(p, msg.Payload)
+	case "getheaders":
+		return n.handleGetHeaders(p, msg.Payload)
+	case "getproofs":
+		return n.handleGetProofs(p, msg.Payload)
+	}
+
+	return fmt.Errorf("unknown command %s", msg.Command)
+}
+
+func (n *Node) sendVersion(p *peer) error {
+	n.mu.RLock()
+	height := n.bc.GetBestHeight()
+	work := n.bc.TipWork()
+	n.mu.RUnlock()
+
+	return p.send("version", versionMsg{protocolVersion, height, work.Bytes(), n.addr})
+}
+
+// version 을 받으면 (받은 쪽이라면 version 을 보낸 뒤) verack 으로 응답
+// 상대 노드의 누적 작업량이 더 많다면 getblocks 로 동기화를 시작
+func (n *Node) handleVersion(p *peer, payload json.RawMessage) error {
+	var v versionMsg
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return err
+	}
+	if p.version {
+		return errors.New("duplicate version message")
+	}
+	if v.Version != protocolVersion {
+		return fmt.Errorf("unsupported protocol version %d", v.Version)
+	}
+	if v.AddrFrom == n.addr {
+		return errors.New("connected to self")
+	}
+
+	p.bestHeight = v.BestHeight
+	p.chainWork = new(big.Int).SetBytes(v.ChainWork)
+	if !n.keepPeer(p, v.AddrFrom) {
+		return fmt.Errorf("already connected to %s", p.addr)
+	}
+
+	if !p.outbound {
+		if err := n.sendVersion(p); err != nil {
+			return err
+		}
+	}
+	if err := p.send("verack", nil); err != nil {
+		return err
+	}
+	log.Printf("Connected to %s (best height %d)", p, p.bestHeight)
+
+	return n.syncFrom(p)
+}
+
+// 상대 노드의 누적 작업량이 더 많다면 getblocks 를 보냄
+// 39) 블록 높이가 아니라 누적 작업량을 비교하므로 블록이 많더라도 작업량이 적은 체인은 받지 않음
+func (n *Node) syncFrom(p *peer) error {
+	n.mu.RLock()
+	work := n.bc.TipWork()
+	n.mu.RUnlock()
+
+	if p.chainWork == nil || p.chainWork.Cmp(work) <= 0 {
+		return nil
+	}
+
+	return n.sendGetBlocks(p)
+}
+
+func (n *Node) sendGetBlocks(p *peer) error {
+	n.mu.RLock()
+	locator := n.bc.BlockLocator()
+	n.mu.RUnlock()
+
+	return p.send("getblocks", getBlocksMsg{locator})
+}
+
+// locator 이후의 블록 해시들을 최대 maxInvBlocks 개까지 inv 로 보냄
+func (n *Node) handleGetBlocks(p *peer, payload json.RawMessage) error {
+	var m getBlocksMsg
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return err
+	}
+
+	n.mu.RLock()
+	hashes := n.bc.BlockHashesAfter(n.bc.FindLocatorHeight(m.Locator), maxInvBlocks)
+	n.mu.RUnlock()
+
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	return p.send("inv", invMsg{invTypeBlock, hashes})
+}
+
+// locator 이후의 블록 헤더들을 최대 maxHeaders 개까지 headers 로 보냄, 없다면 빈 목록
+func (n *Node) handleGetHeaders(p *peer, payload json.RawMessage) error {
+	var m getHeadersMsg
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return err
+	}
+
+	n.mu.RLock()
+	headers, err := n.bc.HeadersAfter(n.bc.FindLocatorHeight(m.Locator), maxHeaders)
+	n.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	return p.send("headers", headersMsg{headers})
+}
+
+// 공개키 해시들로 잠긴 UTXO 가 속한 트랜잭션과 그 머클 증명을 proofs 로 보냄
+func (n *Node) handleGetProofs(p *peer, payload json.RawMessage) error {
+	var m getProofsMsg
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return err
+	}
+
+	var proofs []TxProof
+	n.mu.RLock()
+	for _, pubKeyHash := range m.PubKeyHashes {
+		found, err := n.bc.FindTxProofs(pubKeyHash)
+		if err != nil {
+			n.mu.RUnlock()
+			return err
+		}
+		proofs = append(proofs, found...)
+	}
+	n.mu.RUnlock()
+
+	return p.send("proofs", proofsMsg{proofs})
+}
+
+// inv 로 받은 해시 중 가지고 있지 않은 블록, 트랜잭션을 getdata 로 요청
+func (n *Node) handleInv(p *peer, payload json.RawMessage) error {
+	var m invMsg
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return err
+	}
+
+	var missing [][]byte
+	n.mu.RLock()
+	tipWork := n.bc.TipWork()
+	for _, id := range m.Items {
+		switch m.Type {
+		case invTypeBlock:
+			// 27) rollback 으로 해제되어 저장만 되어 있는 블록도 메인 체인보다 작업량이 많다면 다시 받아 재구성
+			index := n.bc.GetBlockIndex(id)
+			if index == nil || (index.Status != blockStatusInvalid && index.Work().Cmp(tipWork) > 0 && !n.bc.IsMainChain(id, index.Height)) {
+				missing = append(missing, id)
+			}
+		case invTypeTx:
+			// 39) 블록체인 전체를 순회하지 않도록 메모리풀과 UTXO 집합에서만 찾음, 출력이 모두 소비된 트랜잭션은 다시 받더라도 메모리풀에서 거부됨
+			if n.mempool.Get(id) == nil && !n.bc.HasUnspentOutputs(id) {
+				missing = append(missing, id)
+			}
+		}
+	}
+	n.mu.RUnlock()
+
+	if len(missing) == 0 {
+		return nil
+	}
+	if m.Type == invTypeBlock {
+		p.lastInv = missing[len(missing)-1]
+	}
+
+	return p.send("getdata", invMsg{m.Type, missing})
+}
+
+// 요청받은 블록, 트랜잭션을 하나씩 보냄, 가지고 있지 않은 것은 건너뜀
+func (n *Node) handleGetData(p *peer, payload json.RawMessage) error {
+	var m invMsg
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return err
+	}
+	if m.Type != invTypeBlock && m.Type != invTypeTx {
+		return fmt.Errorf("unknown getdata type %s", m.Type)
+	}
+
+	for _, id := range m.Items {
+		var err error
+		if m.Type == invTypeBlock {
+			n.mu.RLock()
+			block, getErr := n.bc.GetBlock(id)
+			n.mu.RUnlock()
+			if getErr == nil {
+				err = p.send("block", block)
+			}
+		} else if tx := n.mempool.Get(id); tx != nil {
+			err = p.send("tx", tx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 받은 블록을 검증하여 추가하고 다른 노드들에게 전파
+// 이전 블록을 모르는 블록이라면 getblocks 로 빠진 블록들을 요청
+// 동기화 중에 요청한 마지막 블록(lastInv)을 받았는데 상대 노드의 누적 작업량이 더 많다면 이어서 getblocks 를 보냄
+// 26) 다른 갈래의 블록은 저장만 하고 전파하지 않으며, 재구성으로 메인 체인에서 해제된 블록의 트랜잭션은 메모리풀로 되돌림
+// 39) 저장된 블록의 누적 작업량이 상대 노드가 알려준 것보다 많다면 상대 노드의 누적 작업량을 갱신
+func (n *Node) handleBlock(p *peer, payload json.RawMessage) error {
+	var block Block
+	if err := json.Unmarshal(payload, &block); err != nil {
+		return err
+	}
+	if block.Height > p.bestHeight {
+		p.bestHeight = block.Height
+	}
+
+	n.mu.Lock()
+	disconnected, err := n.bc.AcceptBlock(&block)
+	isTip := err == nil && bytes.Equal(n.bc.Tip(), block.Hash)
+	if isTip {
+		n.mempool.AddDisconnected(n.bc, disconnected)
+	}
+	if err == nil || errors.Is(err, errBlockExists) {
+		if index := n.bc.GetBlockIndex(block.Hash); index != nil && (p.chainWork == nil || index.Work().Cmp(p.chainWork) > 0) {
+			p.chainWork = index.Work()
+		}
+	}
+	n.mu.Unlock()
+
+	switch {
+	case isTip:
+		if len(disconnected) > 0 {
+			log.Printf("Reorganized to block %d %x, disconnected %d blocks", block.Height, block.Hash, len(disconnected))
+		}
+		log.Printf("Accepted block %d %x from %s", block.Height, block.Hash, p)
+		n.broadcast("inv", invMsg{invTypeBlock, [][]byte{block.Hash}}, p)
+	case err == nil:
+		log.Printf("Stored side branch block %d %x from %s", block.Height, block.Hash, p)
+	case errors.Is(err, errBlockExists):
+	case errors.Is(err, errOrphanBlock):
+		// 이전 블록을 모르므로 누적 작업량을 계산할 수 없고, 상대 노드가 모르는 블록을 가지고 있으므로 바로 요청
+		p.lastInv = nil
+		return n.sendGetBlocks(p)
+	default:
+		log.Printf("Rejected block %x from %s: %v", block.Hash, p, err)
+	}
+
+	if p.lastInv != nil && bytes.Equal(block.Hash, p.lastInv) {
+		p.lastInv = nil
+		return n.syncFrom(p)
+	}
+
+	return nil
+}
+
+// 받은 트랜잭션을 메모리풀에 추가하고 다른 노드들에게 전파
+func (n *Node) handleTx(p *peer, payload json.RawMessage) error {
+	var tx Transaction
+	if err := json.Unmarshal(payload, &tx); err != nil {
+		return err
+	}
+	if !bytes.Equal(tx.ID, tx.Hash()) {
+		return fmt.Errorf("transaction %x has an invalid id", tx.ID)
+	}
+	if n.mempool.Get(tx.ID) != nil {
+		return nil
+	}
+
+	n.mu.RLock()
+	err := n.mempool.Add(n.bc, &tx)
+	n.mu.RUnlock()
+	if err != nil {
+		log.Printf("Rejected transaction %x from %s: %v", tx.ID, p, err)
+		return nil
+	}
+
+	log.Printf("Accepted transaction %x from %s", tx.ID, p)
+	n.broadcast("inv", invMsg{invTypeTx, [][]byte{tx.ID}}, p)
+
+	return nil
+}
+
+// 메모리풀에 추가된 트랜잭션을 모든 노드에게 전파
+func (n *Node) relayTx(tx *Transaction) {
+	n.broadcast("inv", invMsg{invTypeTx, [][]byte{tx.ID}}, nil)
+}
+
+// handshake 가 끝난 모든 노드(except 제외)에게 메시지를 보냄
+func (n *Node) broadcast(command string, payload interface{}, except *peer) {
+	n.peersMu.Lock()
+	var peers []*peer
+	for p := range n.peers {
+		if p != except && p.version {
+			peers = append(peers, p)
+		}
+	}
+	n.peersMu.Unlock()
+
+	for _, p := range peers {
+		if err := p.send(command, payload); err != nil {
+			log.Printf("peer %s: %v", p, err)
+			p.conn.Close()
+		}
+	}
+}
+
+func (n *Node) addPeer(p *peer) {
+	n.peersMu.Lock()
+	defer n.peersMu.Unlock()
+
+	if n.closed {
+		p.conn.Close()
+	}
+	n.peers[p] = true
+}
+
+func (n *Node) removePeer(p *peer) {
+	n.peersMu.Lock()
+	delete(n.peers, p)
+	n.peersMu.Unlock()
+
+	p.conn.Close()
+	if p.version {
+		log.Printf("Disconnected from %s", p)
+	}
+}
+
+// addr 노드와 연결되어 있는지(연결 중인 경우 포함) 확인
+func (n *Node) connected(addr string) bool {
+	n.peersMu.Lock()
+	defer n.peersMu.Unlock()
+
+	for p := range n.peers {
+		if p.addr == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+// version 을 받은 노드를 handshake 가 끝난 노드로 표시하기 위한 메서드
+// 같은 노드와 두 개의 연결이 생긴 경우(서로를 seeds 로 지정한 경우) 하나만 남기며,
+// 양쪽 노드가 같은 결정을 내리도록 주소가 작은 노드가 연결한 쪽을 남기고 다른 연결은 닫음
+// addr 은 version 메시지의 접속 주소로, connected() 가 다른 고루틴에서 읽으므로 peersMu 를 잡고 바꿈
+func (n *Node) keepPeer(p *peer, addr string) bool {
+	n.peersMu.Lock()
+	defer n.peersMu.Unlock()
+
+	p.addr = addr
+
+	canonical := func(q *peer) bool {
+		return q.outbound == (n.addr < q.addr)
+	}
+	for q := range n.peers {
+		// 28) 접속 주소가 없는 경량 클라이언트는 중복 연결로 보지 않음
+		if q == p || q.addr != p.addr || p.addr == "" {
+			continue
+		}
+		if !canonical(p) {
+			return false
+		}
+		q.conn.Close()
+	}
+	p.version = true
+
+	return true
+}
+
+func (p *peer) send(command string, payload interface{}) error {
+	msg := message{Command: command}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		msg.Payload = data
+	}
+
+	p.sendMu.Lock()
+	defer p.sendMu.Unlock()
+
+	p.conn.SetWriteDeadline(time.Now().Add(sendTimeout))
+
+	return p.enc.Encode(msg)
+}
+
+func (p *peer) String() string {
+	if p.addr != "" {
+		return p.addr
+	}
+
+	return p.conn.RemoteAddr().String()
+}
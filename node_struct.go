@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"net"
+	"sync"
+)
+
+// 25. P2P 네트워크의 노드
+// 하나의 Blockchain 과 Mempool 을 가지고 다른 노드(peer)들과 블록, 트랜잭션을 주고받음
+// mu 는 블록체인을 변경하는 작업(블록 추가, 채굴)과 조회를 구분하기 위해 사용하며 RPC 서버와 공유
+// ln, closed, conns 는 Close 로 노드를 멈추기 위해 사용하며 peersMu 로 보호(conns 는 연결을 처리하는 고루틴 수)
+type Node struct {
+	bc           *Blockchain
+	mempool      *Mempool
+	addr         string
+	seeds        []string
+	minerAddress string
+	mu           sync.RWMutex
+
+	peersMu sync.Mutex
+	peers   map[*peer]bool
+	ln      net.Listener
+	closed  bool
+	quit    chan struct{}
+	conns   sync.WaitGroup
+}
+
+// 연결된 노드
+// addr 은 상대 노드가 version 메시지로 알려준 접속 주소, bestHeight 는 상대 노드의 마지막 블록 높이
+// chainWork 는 상대 노드의 누적 작업량으로, 동기화 여부는 블록 높이가 아니라 이 값으로 판단
+// lastInv 는 마지막으로 받은 블록 inv 의 마지막 해시로, 이 블록을 받으면 이어서 getblocks 를 보냄
+type peer struct {
+	conn       net.Conn
+	enc        *json.Encoder
+	sendMu     sync.Mutex
+	addr       string
+	outbound   bool
+	version    bool
+	bestHeight int
+	chainWork  *big.Int
+	lastInv    []byte
+}
+
+// 노드 사이에 주고받는 메시지, Payload 는 Command 에 따라 다름
+type message struct {
+	Command string          `json:"command"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// 연결 직후 서로 보내는 메시지, ChainWork 는 마지막 블록의 누적 작업량(big-endian)
+type versionMsg struct {
+	Version    int    `json:"version"`
+	BestHeight int    `json:"bestHeight"`
+	ChainWork  []byte `json:"chainWork"`
+	AddrFrom   string `json:"addrFrom"`
+}
+
+// 가지고 있는 블록들의 해시(locator) 이후의 블록 목록을 요청
+type getBlocksMsg struct {
+	Locator [][]byte `json:"locator"`
+}
+
+// 가지고 있는 블록 또는 트랜잭션의 해시 목록(inv), 또는 받고 싶은 블록 또는 트랜잭션의 해시 목록(getdata)
+type invMsg struct {
+	Type  string   `json:"type"`
+	Items [][]byte `json:"items"`
+}
+
+// 28. 경량 클라이언트가 가지고 있는 블록 헤더들의 해시(locator) 이후의 블록 헤더 목록을 요청
+type getHeadersMsg struct {
+	Locator [][]byte `json:"locator"`
+}
+
+// getheaders 의 응답
+type headersMsg struct {
+	Headers []BlockHeader `json:"headers"`
+}
+
+// 공개키 해시들로 잠긴 UTXO 가 속한 트랜잭션과 그 머클 증명을 요청
+type getProofsMsg struct {
+	PubKeyHashes [][]byte `json:"pubKeyHashes"`
+}
+
+// getproofs 의 응답
+type proofsMsg struct {
+	Proofs []TxProof `json:"proofs"`
+}
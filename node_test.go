@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 사용하지 않는 localhost 의 주소
+func freeTestAddr(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	return ln.Addr().String()
+}
+
+// addr 에서 노드를 시작하고 연결을 받을 수 있을 때까지 기다리기 위한 테스트 함수, 테스트가 끝나면 멈춤
+func startTestNode(t *testing.T, bc *Blockchain, addr string, seeds ...string) *Node {
+	t.Helper()
+
+	n := NewNode(bc, addr, seeds, "")
+	done := make(chan error, 1)
+	go func() { done <- n.Start(0) }()
+	t.Cleanup(func() {
+		n.Close()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	})
+
+	waitFor(t, "node to listen", func() bool {
+		conn, err := net.Dial("tcp", n.addr)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	})
+
+	return n
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(10 * time.Second); !cond(); time.Sleep(20 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+// localhost 의 두 노드가 연결 후 블록을 동기화하고, 새로 채굴한 블록을 전파하는지 검사
+// 두 노드가 서로를 seeds 로 지정하므로 동시에 두 연결이 생기고 하나만 남음(keepPeer)
+// go test -race 로 실행
+func TestNodeSyncAndRelay(t *testing.T) {
+	a := newTestBlockchain(t)
+	b := newTestBlockchain(t)
+	address := newTestAddress()
+	if _, err := a.Generate(5, address); err != nil {
+		t.Fatal(err)
+	}
+
+	addrA, addrB := freeTestAddr(t), freeTestAddr(t)
+	nodeA := startTestNode(t, a, addrA, addrB)
+	nodeB := startTestNode(t, b, addrB, addrA)
+	waitFor(t, "initial sync", func() bool { return b.GetBestHeight() == 5 })
+	waitFor(t, "a single connection", func() bool { return len(connectedPeers(nodeA)) == 1 && len(connectedPeers(nodeB)) == 1 })
+
+	nodeA.mu.Lock()
+	blocks, err := a.Generate(1, address)
+	nodeA.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeA.broadcast("inv", invMsg{invTypeBlock, [][]byte{blocks[0].Hash}}, nil)
+	waitFor(t, "block relay", func() bool { return b.GetBestHeight() == 6 })
+
+	for height := 0; height <= 6; height++ {
+		hashA, _ := a.GetBlockHash(height)
+		hashB, err := b.GetBlockHash(height)
+		if err != nil || string(hashA) != string(hashB) {
+			t.Fatalf("block at height %d = %x, want %x (%v)", height, hashB, hashA, err)
+		}
+	}
+}
+
+// 다른 사람의 출력을 자신의 키로 서명한 트랜잭션을 담은 블록을 받은 노드는 그 블록을 거부하고 다음 블록을 이어서 받음
+// 같은 연결의 메시지는 순서대로 처리되므로, 뒤에 보낸 정상 블록을 받았다면 앞의 블록은 이미 처리된 것
+func TestNodeRejectsStealingBlock(t *testing.T) {
+	a := newTestBlockchain(t)
+	b := newTestBlockchain(t)
+	victim, attacker := NewWallet(), NewWallet()
+	if _, err := a.Generate(2, victim.GetAddress()); err != nil {
+		t.Fatal(err)
+	}
+
+	addrA, addrB := freeTestAddr(t), freeTestAddr(t)
+	nodeA := startTestNode(t, a, addrA)
+	startTestNode(t, b, addrB, addrA)
+	waitFor(t, "initial sync", func() bool { return b.GetBestHeight() == 2 })
+	waitFor(t, "a connection", func() bool { return len(connectedPeers(nodeA)) == 1 })
+
+	nodeA.mu.Lock()
+	steal := newTestTransaction(t, a, victim, attacker, attacker.GetAddress(), GetBlockSubsidy(1))
+	timestamp, err := a.nextBlockTime()
+	tip := a.Tip()
+	nodeA.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stealing := newBlockAt([]*Transaction{steal}, tip, 3, timestamp)
+	nodeA.broadcast("block", stealing, nil)
+
+	nodeA.mu.Lock()
+	blocks, err := a.Generate(1, victim.GetAddress())
+	nodeA.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeA.broadcast("inv", invMsg{invTypeBlock, [][]byte{blocks[0].Hash}}, nil)
+	waitFor(t, "block relay", func() bool { return b.GetBestHeight() == 3 })
+
+	if tip := b.Tip(); string(tip) != string(blocks[0].Hash) {
+		t.Fatalf("tip = %x, want %x (stealing block %x)", tip, blocks[0].Hash, stealing.Hash)
+	}
+	if _, err := b.GetBlock(stealing.Hash); err == nil {
+		t.Fatalf("stealing block %x was stored", stealing.Hash)
+	}
+	if balance := b.GetBalance(victim.GetAddress()); balance != GetBlockSubsidy(1)*3 {
+		t.Fatalf("victim balance = %d, want %d", balance, GetBlockSubsidy(1)*3)
+	}
+}
+
+// 동기화 여부는 블록 높이가 아니라 누적 작업량으로 판단
+func TestSyncFromChainWork(t *testing.T) {
+	bc := newTestBlockchain(t)
+	if _, err := bc.Generate(2, newTestAddress()); err != nil {
+		t.Fatal(err)
+	}
+	n := NewNode(bc, "", nil, "")
+	work := bc.TipWork()
+
+	tests := []struct {
+		name       string
+		bestHeight int
+		chainWork  *big.Int
+		want       bool
+	}{
+		{"more blocks but less work", 100, new(big.Int).Sub(work, big.NewInt(1)), false},
+		{"equal work", 2, work, false},
+		{"fewer blocks but more work", 1, new(big.Int).Add(work, big.NewInt(1)), true},
+		{"unknown work", 100, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, remote := net.Pipe()
+			defer remote.Close()
+			p := &peer{conn: local, enc: json.NewEncoder(local), bestHeight: tt.bestHeight, chainWork: tt.chainWork}
+			done := make(chan error, 1)
+			go func() {
+				done <- n.syncFrom(p)
+				local.Close()
+			}()
+
+			var msg message
+			err := json.NewDecoder(remote).Decode(&msg)
+			if got := err == nil && msg.Command == "getblocks"; got != tt.want {
+				t.Fatalf("sent getblocks = %v (command %q, err %v), want %v", got, msg.Command, err, tt.want)
+			}
+			if err != nil && err != io.EOF {
+				t.Fatal(err)
+			}
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// inv 로 받은 트랜잭션은 메모리풀과 UTXO 집합에서만 찾음
+func TestHandleInvTx(t *testing.T) {
+	bc := newTestBlockchain(t)
+	wallet, to := NewWallet(), newTestAddress()
+	if _, err := bc.Generate(2, wallet.GetAddress()); err != nil {
+		t.Fatal(err)
+	}
+	spend := newTestTransaction(t, bc, wallet, wallet, to, GetBlockSubsidy(1))
+	spent := spend.Vin[0].Txid
+	if _, err := bc.AddBlock([]*Transaction{spend}); err != nil {
+		t.Fatal(err)
+	}
+	n := NewNode(bc, "", nil, "")
+	pending := newTestTransaction(t, bc, wallet, wallet, to, 1)
+	if err := n.mempool.Add(bc, pending); err != nil {
+		t.Fatal(err)
+	}
+	unknown := bytes.Repeat([]byte{0xab}, 32)
+
+	if bc.HasUnspentOutputs(spent) {
+		t.Fatalf("spent transaction %x has unspent outputs", spent)
+	}
+	if !bc.HasUnspentOutputs(spend.ID) {
+		t.Fatalf("transaction %x has no unspent outputs", spend.ID)
+	}
+
+	local, remote := net.Pipe()
+	defer remote.Close()
+	p := &peer{conn: local, enc: json.NewEncoder(local)}
+	payload, err := json.Marshal(invMsg{invTypeTx, [][]byte{spend.ID, pending.ID, unknown}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- n.handleInv(p, payload)
+		local.Close()
+	}()
+
+	var msg message
+	if err := json.NewDecoder(remote).Decode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	var m invMsg
+	if err := json.Unmarshal(msg.Payload, &m); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Command != "getdata" || len(m.Items) != 1 || !bytes.Equal(m.Items[0], unknown) {
+		t.Fatalf("sent %s %x, want getdata %x", msg.Command, m.Items, unknown)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// maxMessageSize 보다 큰 메시지를 보내면 연결을 끊음
+func TestNodeMessageSizeLimit(t *testing.T) {
+	n := startTestNode(t, newTestBlockchain(t), freeTestAddr(t))
+	conn, err := net.Dial("tcp", n.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	go func() {
+		io.WriteString(conn, `{"command":"version","payload":"`)
+		chunk := strings.Repeat("a", 1<<20)
+		for i := 0; i <= maxMessageSize>>20; i++ {
+			if _, err := io.WriteString(conn, chunk); err != nil {
+				return
+			}
+		}
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		t.Fatal("connection was not closed")
+	}
+	if err == nil {
+		t.Fatal("read a reply to an oversized message")
+	}
+}
+
+// handshake 가 끝난 연결들의 주소
+func connectedPeers(n *Node) []string {
+	n.peersMu.Lock()
+	defer n.peersMu.Unlock()
+
+	var addrs []string
+	for p := range n.peers {
+		if p.version {
+			addrs = append(addrs, p.addr)
+		}
+	}
+
+	return addrs
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 16. 오프라인 서명 기능 추가
+// 서명에는 wallet.json 과 chain.db 가 같은 기기에 있어야 했기 때문에, 서명 단계를 분리
+//  1. createrawtx  : (온라인, 개인키 없음) 서명되지 않은 트랜잭션과 이전 트랜잭션들을 내보냄
+//  2. signrawtx    : (오프라인, 키스토어만) TrimmedCopy(), Sign() 을 사용해 서명
+//  3. decoderawtx  : 트랜잭션의 내용(입력, 출력, 수수료, 서명 여부)을 확인
+//  4. sendrawtx    : (온라인) Verify() 로 검증한 뒤 블록에 추가
+// 주고 받는 형식은 RawTransaction 을 json 으로 직렬화한 뒤 hex 로 인코딩한 문자열
+
+// 트랜잭션이 참조하는 이전 트랜잭션들을 찾아 RawTransaction 을 만들기 위한 메서드
+func (bc *Blockchain) NewRawTransaction(tx *Transaction) (*RawTransaction, error) {
+	raw := &RawTransaction{tx, nil}
+	seen := make(map[string]bool)
+
+	for _, in := range tx.Vin {
+		txID := hex.EncodeToString(in.Txid)
+		if seen[txID] {
+			continue
+		}
+		seen[txID] = true
+
+		prevTX := bc.FindTransaction(in.Txid)
+		if prevTX == nil {
+			return nil, fmt.Errorf("previous transaction %s not found", txID)
+		}
+		raw.PrevTXs = append(raw.PrevTXs, prevTX)
+	}
+
+	return raw, nil
+}
+
+// RawTransaction 을 hex 문자열로 인코딩하기 위한 메서드
+func (raw *RawTransaction) Encode() string {
+	result, err := json.Marshal(raw)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return hex.EncodeToString(result)
+}
+
+// hex 문자열을 RawTransaction 으로 디코딩하기 위한 함수
+func DecodeRawTransaction(s string) (*RawTransaction, error) {
+	data, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw transaction: %v", err)
+	}
+
+	var raw RawTransaction
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid raw transaction: %v", err)
+	}
+	if raw.Tx == nil || len(raw.Tx.Vin) == 0 || len(raw.Tx.Vout) == 0 {
+		return nil, fmt.Errorf("invalid raw transaction: missing inputs or outputs")
+	}
+	if raw.Tx.IsCoinbase() {
+		return nil, fmt.Errorf("invalid raw transaction: coinbase transactions cannot be relayed")
+	}
+
+	return &raw, nil
+}
+
+// 함께 받은 이전 트랜잭션들을 Sign(), Verify() 에 넘길 수 있는 형태로 만들기 위한 메서드
+// 이전 트랜잭션의 내용이 ID 와 맞지 않거나(변조), 입력이 참조하는 출력이 없으면 오류를 반환
+func (raw *RawTransaction) PrevTXMap() (map[string]*Transaction, error) {
+	prevTXs := make(map[string]*Transaction)
+
+	for _, prevTX := range raw.PrevTXs {
+		if bytes.Compare(prevTX.Hash(), prevTX.ID) != 0 {
+			return nil, fmt.Errorf("previous transaction %x does not match its ID", prevTX.ID)
+		}
+		prevTXs[hex.EncodeToString(prevTX.ID)] = prevTX
+	}
+
+	for _, in := range raw.Tx.Vin {
+		prevTX := prevTXs[hex.EncodeToString(in.Txid)]
+		if prevTX == nil || in.Vout < 0 || in.Vout >= len(prevTX.Vout) {
+			return nil, fmt.Errorf("previous output %x:%d not included", in.Txid, in.Vout)
+		}
+	}
+
+	return prevTXs, nil
+}
+
+// 키스토어의 개인키로 서명하기 위한 메서드, 블록체인에 접근하지 않음
+func (raw *RawTransaction) Sign(lookup KeyLookup) error {
+	prevTXs, err := raw.PrevTXMap()
+	if err != nil {
+		return err
+	}
+
+	return raw.Tx.Sign(lookup, prevTXs)
+}
+
+// 모든 입력이 서명되었는지 확인하기 위한 메서드
+func (raw *RawTransaction) IsSigned() bool {
+	for _, in := range raw.Tx.Vin {
+		if len(in.Signature) == 0 || len(in.PubKey) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// 입력의 합과 출력의 합의 차이(수수료)를 구하기 위한 메서드
+func (raw *RawTransaction) Fee() (uint64, error) {
+	prevTXs, err := raw.PrevTXMap()
+	if err != nil {
+		return 0, err
+	}
+
+	var in, out uint64
+	for _, vin := range raw.Tx.Vin {
+		in += prevTXs[hex.EncodeToString(vin.Txid)].Vout[vin.Vout].Value
+	}
+	for _, vout := range raw.Tx.Vout {
+		out += vout.Value
+	}
+	if out > in {
+		return 0, fmt.Errorf("outputs (%d) exceed inputs (%d)", out, in)
+	}
+
+	return in - out, nil
+}
+
+// 서명된 트랜잭션을 검증하여 블록에 추가하기 위한 메서드
+// 함께 받은 이전 트랜잭션이 아닌 블록체인의 트랜잭션으로 검증(ValidateTransaction)
+// 31) 검증한 뒤 블록에 추가할 때까지 다른 블록이 추가되지 않도록 쓰기 잠금을 잡고 실행
+func (bc *Blockchain) SendRawTransaction(raw *RawTransaction) error {
+	bc.writeMu.Lock()
+	defer bc.writeMu.Unlock()
+
+	if err := bc.ValidateTransaction(raw.Tx); err != nil {
+		return err
+	}
+
+	_, err := bc.addBlock([]*Transaction{raw.Tx})
+
+	return err
+}
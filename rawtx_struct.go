@@ -0,0 +1,8 @@
+package main
+
+// 서명되지 않은(또는 일부만 서명된) 트랜잭션을 다른 기기로 옮기기 위한 구조체
+// 서명과 검증에 필요한 이전 트랜잭션들(PrevTXs)을 함께 담아, 블록체인(chain.db) 없이 키스토어만으로 서명할 수 있도록 함
+type RawTransaction struct {
+	Tx      *Transaction
+	PrevTXs []*Transaction
+}
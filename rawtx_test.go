@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+// ID 가 내용의 해시와 다른 트랜잭션은 sendrawtx 로 블록에 추가할 수 없음
+func TestSendRawTransactionForgedID(t *testing.T) {
+	bc := newTestBlockchain(t)
+	wallet := NewWallet()
+	blocks, err := bc.Generate(1, wallet.GetAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := newTestTransaction(t, bc, wallet, wallet, newTestAddress(), 1)
+
+	tests := []struct {
+		name string
+		id   []byte
+	}{
+		{"existing txid", blocks[0].Transactions[0].ID},
+		{"random id", make([]byte, 32)},
+		{"no id", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forged := *tx
+			forged.ID = tt.id
+			raw, err := bc.NewRawTransaction(&forged)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := bc.SendRawTransaction(raw); err == nil {
+				t.Fatal("sent a transaction with a forged id")
+			}
+			if height := bc.GetBestHeight(); height != 1 {
+				t.Fatalf("height = %d, want 1", height)
+			}
+		})
+	}
+
+	raw, err := bc.NewRawTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.SendRawTransaction(raw); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 14. 다수의 수신자에게 한 번에 보내기
+// 수신자는 "주소:금액" 형식의 문자열이나 CSV/JSON 파일로 받음
+//   - CSV  : 한 줄에 "주소,금액" (첫 줄이 address,amount 헤더인 경우 무시)
+//   - JSON : [{"address": "...", "amount": 1}, ...]
+
+// "주소:금액" 형식의 문자열을 Recipient 로 변환하기 위한 함수
+func ParseRecipient(s string) (Recipient, error) {
+	sep := strings.LastIndex(s, ":")
+	if sep < 0 {
+		return Recipient{}, fmt.Errorf("invalid recipient '%s', expected address:amount", s)
+	}
+
+	return newRecipient(s[:sep], s[sep+1:])
+}
+
+func newRecipient(address, amount string) (Recipient, error) {
+	address = strings.TrimSpace(address)
+	value, err := strconv.ParseUint(strings.TrimSpace(amount), 10, 64)
+	if err != nil || value == 0 {
+		return Recipient{}, fmt.Errorf("invalid amount '%s' for '%s'", amount, address)
+	}
+	if !ValidateAddress(address) {
+		return Recipient{}, fmt.Errorf("invalid address '%s'", address)
+	}
+
+	return Recipient{address, value}, nil
+}
+
+// 수신자 목록 파일을 읽기 위한 함수, 확장자가 .json 이면 JSON 으로 그 외에는 CSV 로 읽음
+func LoadRecipients(path string) ([]Recipient, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var entries []Recipient
+		if err := json.NewDecoder(file).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		// CSV 와 같이 newRecipient() 가 검증하고 공백을 제거한 수신자를 반환
+		recipients := make([]Recipient, 0, len(entries))
+		for i, entry := range entries {
+			r, err := newRecipient(entry.Address, strconv.FormatUint(entry.Amount, 10))
+			if err != nil {
+				return nil, fmt.Errorf("%s: entry %d: %v", path, i, err)
+			}
+			recipients = append(recipients, r)
+		}
+		return recipients, nil
+	}
+
+	var recipients []Recipient
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if line == 1 && strings.EqualFold(record[0], "address") {
+			continue
+		}
+
+		r, err := newRecipient(record[0], record[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: %v", path, line, err)
+		}
+		recipients = append(recipients, r)
+	}
+
+	return recipients, nil
+}
+
+// 수신자들에게 보낼 금액의 총 합
+// 총 합이 최대 발행량을 넘는 경우(또는 overflow) 오류를 반환
+func TotalAmount(recipients []Recipient) (uint64, error) {
+	var total uint64
+	for _, r := range recipients {
+		total += r.Amount
+		if r.Amount > MaxMoney() || total > MaxMoney() {
+			return 0, fmt.Errorf("total amount exceeds max money %d", MaxMoney())
+		}
+	}
+
+	return total, nil
+}
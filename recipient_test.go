@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// regtest 주소 두 개, 테스트가 끝나면 netParams 를 되돌림
+func testRecipientAddresses(t *testing.T) (string, string) {
+	t.Helper()
+
+	params := netParams
+	netParams = &RegTestParams
+	t.Cleanup(func() { netParams = params })
+
+	return newTestAddress(), newTestAddress()
+}
+
+func TestParseRecipient(t *testing.T) {
+	a, _ := testRecipientAddresses(t)
+
+	tests := []struct {
+		name string
+		in   string
+		want Recipient
+		ok   bool
+	}{
+		{"valid", a + ":5", Recipient{a, 5}, true},
+		{"spaces", " " + a + " : 5 ", Recipient{a, 5}, true},
+		{"no amount", a, Recipient{}, false},
+		{"zero", a + ":0", Recipient{}, false},
+		{"negative", a + ":-1", Recipient{}, false},
+		{"invalid address", "1abc:5", Recipient{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRecipient(tt.in)
+			if (err == nil) != tt.ok {
+				t.Fatalf("ParseRecipient(%q) error = %v, want ok %t", tt.in, err, tt.ok)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseRecipient(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// CSV, JSON 파일 모두 공백을 제거하고 검증한 수신자를 반환
+func TestLoadRecipients(t *testing.T) {
+	a, b := testRecipientAddresses(t)
+	want := fmt.Sprint([]Recipient{{a, 1}, {b, 2}})
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		ok      bool
+	}{
+		{"csv", "r.csv", a + ",1\n" + b + ",2\n", true},
+		{"csv header and comment", "r.csv", "address,amount\n# comment\n" + a + ", 1\n " + b + " ,2\n", true},
+		{"csv invalid amount", "r.csv", a + ",x\n", false},
+		{"csv invalid address", "r.csv", "1abc,1\n", false},
+		{"csv missing field", "r.csv", a + "\n", false},
+		{"json", "r.json", fmt.Sprintf(`[{"address":"%s","amount":1},{"address":"%s","amount":2}]`, a, b), true},
+		{"json spaces", "r.JSON", fmt.Sprintf(`[{"address":" %s ","amount":1},{"address":"%s\t","amount":2}]`, a, b), true},
+		{"json zero amount", "r.json", fmt.Sprintf(`[{"address":"%s","amount":0}]`, a), false},
+		{"json invalid address", "r.json", `[{"address":"1abc","amount":1}]`, false},
+		{"json malformed", "r.json", `[{"address":`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			recipients, err := LoadRecipients(path)
+			if (err == nil) != tt.ok {
+				t.Fatalf("LoadRecipients() error = %v, want ok %t", err, tt.ok)
+			}
+			if got := fmt.Sprint(recipients); tt.ok && got != want {
+				t.Fatalf("LoadRecipients() = %s, want %s", got, want)
+			}
+		})
+	}
+
+	if _, err := LoadRecipients(filepath.Join(t.TempDir(), "missing.csv")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadRecipients() of a missing file error = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func TestTotalAmount(t *testing.T) {
+	a, b := testRecipientAddresses(t)
+
+	tests := []struct {
+		name       string
+		recipients []Recipient
+		want       uint64
+		ok         bool
+	}{
+		{"sum", []Recipient{{a, 1}, {b, 2}}, 3, true},
+		{"max money", []Recipient{{a, MaxMoney()}}, MaxMoney(), true},
+		{"over max money", []Recipient{{a, MaxMoney()}, {b, 1}}, 0, false},
+		{"overflow", []Recipient{{a, ^uint64(0)}, {b, 2}}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TotalAmount(tt.recipients)
+			if (err == nil) != tt.ok || got != tt.want {
+				t.Fatalf("TotalAmount() = %d, %v, want %d (ok %t)", got, err, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// 35. regtest 시험 기능
+// 통합 테스트에서 작업증명을 기다리지 않고 정해진 시간으로 블록을 만들기 위한 기능으로 regtest 에서만 사용 가능
+//   - 난이도 : RegTestParams.TargetBits 를 최소값(1)으로 하여 nonce 를 평균 두 번만 시도
+//   - generate N -address X : X 에게 보상을 주는 블록 N 개를 채굴(rpc generate 도 같음)
+//   - mocktime : 전역 옵션 -mocktime 또는 rpc setmocktime 으로 새 블록의 시간을 고정, 0 이면 현재 시간
+var mockTime int64
+
+var errRegTestOnly = errors.New("only available on regtest")
+
+// 새 블록의 시간을 고정하기 위한 함수, 0 이면 고정하지 않음
+func SetMockTime(t int64) error {
+	if netParams != &RegTestParams {
+		return errRegTestOnly
+	}
+	if t < 0 {
+		return errors.New("mock time must not be negative")
+	}
+	atomic.StoreInt64(&mockTime, t)
+
+	return nil
+}
+
+// 새 블록의 시간, mocktime 이 있다면 그 시간
+func currentTime() int64 {
+	if t := atomic.LoadInt64(&mockTime); t > 0 {
+		return t
+	}
+
+	return time.Now().Unix()
+}
+
+// address 에게 보상을 주는 빈 블록 n 개를 채굴하기 위한 메서드
+func (bc *Blockchain) Generate(n int, address string) ([]*Block, error) {
+	if netParams != &RegTestParams {
+		return nil, errRegTestOnly
+	}
+	if !ValidateAddress(address) {
+		return nil, errors.New("invalid address '" + address + "'")
+	}
+
+	blocks := make([]*Block, 0, n)
+	for i := 0; i < n; i++ {
+		block, err := bc.MineBlock(nil, address)
+		if err != nil {
+			return blocks, err
+		}
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
+}
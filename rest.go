@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+// 23. 블록 탐색기를 위한 REST API
+// 대시보드에서 조회할 수 있도록 읽기 전용 REST 엔드포인트를 제공하며, 응답은 BlockView 등을 사용하여 해시를 hex 로 표현한 json
+//   - GET /blocks?limit=N           : 마지막 블록부터 N개(기본 10)의 블록
+//   - GET /blocks/{hash}            : 해시로 조회한 블록
+//   - GET /blocks/height/{n}        : 높이로 조회한 블록
+//   - GET /tx/{id}                  : 트랜잭션과 트랜잭션이 포함된 블록
+//   - GET /address/{addr}/utxos     : 주소의 UTXO 목록
+//   - GET /address/{addr}/balance   : 주소의 잔액
+const (
+	defaultBlockLimit = 10
+)
+
+// 응답 상태 코드를 가지는 오류
+type restStatusError struct {
+	status int
+	error
+}
+
+func restNotFound(format string, a ...interface{}) error {
+	return restStatusError{http.StatusNotFound, fmt.Errorf(format, a...)}
+}
+
+func restBadRequest(format string, a ...interface{}) error {
+	return restStatusError{http.StatusBadRequest, fmt.Errorf(format, a...)}
+}
+
+func NewRESTServer(bc *Blockchain) *RESTServer {
+	return &RESTServer{bc}
+}
+
+// localhost:port 에서 요청을 받기 시작
+func (s *RESTServer) ListenAndServe(port int) error {
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	log.Printf("REST server listening on %s", addr)
+
+	return http.ListenAndServe(addr, s)
+}
+
+// HTTP 요청 처리, 경로를 나누어 알맞은 메서드를 호출하고 결과를 json 으로 응답
+func (s *RESTServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, restError{"only GET is supported"})
+		return
+	}
+
+	result, err := s.route(r)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var statusErr restStatusError
+		if errors.As(err, &statusErr) {
+			status = statusErr.status
+		}
+		writeJSON(w, status, restError{err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// 경로에 해당하는 메서드를 호출하기 위한 메서드
+// 블록체인 코드의 log.Panic 은 500 오류로 바꿈
+func (s *RESTServer) route(r *http.Request) (result interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			result, err = nil, fmt.Errorf("%v", p)
+		}
+	}()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "blocks":
+		return s.blocks(r.URL.Query().Get("limit"))
+	case len(parts) == 2 && parts[0] == "blocks":
+		return s.blockByHash(parts[1])
+	case len(parts) == 3 && parts[0] == "blocks" && parts[1] == "height":
+		return s.blockByHeight(parts[2])
+	case len(parts) == 2 && parts[0] == "tx":
+		return s.transaction(parts[1])
+	case len(parts) == 3 && parts[0] == "address" && parts[2] == "utxos":
+		return s.addressUTXOs(parts[1])
+	case len(parts) == 3 && parts[0] == "address" && parts[2] == "balance":
+		return s.addressBalance(parts[1])
+	}
+
+	return nil, restNotFound("no such endpoint %s", r.URL.Path)
+}
+
+// blockchainIterator 로 마지막 블록부터 limit 개의 블록을 조회
+func (s *RESTServer) blocks(limit string) (interface{}, error) {
+	n := defaultBlockLimit
+	if limit != "" {
+		var err error
+		if n, err = strconv.Atoi(limit); err != nil || n <= 0 {
+			return nil, restBadRequest("invalid limit '%s'", limit)
+		}
+	}
+
+	views := []BlockView{}
+	bci := NewBlockchainIterator(s.bc)
+	for bci.HasNext() && len(views) < n {
+		views = append(views, NewBlockView(bci.Next()))
+	}
+
+	return views, nil
+}
+
+func (s *RESTServer) blockByHash(hash string) (interface{}, error) {
+	h, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, restBadRequest("invalid block hash '%s'", hash)
+	}
+
+	block, err := s.bc.GetBlock(h)
+	if err != nil {
+		return nil, restNotFound("%v", err)
+	}
+
+	return NewBlockView(block), nil
+}
+
+func (s *RESTServer) blockByHeight(height string) (interface{}, error) {
+	n, err := strconv.Atoi(height)
+	if err != nil || n < 0 {
+		return nil, restBadRequest("invalid block height '%s'", height)
+	}
+
+	block, err := s.bc.GetBlockByHeight(n)
+	if err != nil {
+		return nil, restNotFound("%v", err)
+	}
+
+	return NewBlockView(block), nil
+}
+
+// FindTransaction 과 같이 블록들을 순회하여 트랜잭션을 찾고, 트랜잭션이 포함된 블록도 함께 응답
+func (s *RESTServer) transaction(txid string) (interface{}, error) {
+	id, err := hex.DecodeString(txid)
+	if err != nil {
+		return nil, restBadRequest("invalid txid '%s'", txid)
+	}
+
+	tx, block := s.bc.FindTransactionBlock(id)
+	if tx == nil {
+		return nil, restNotFound("transaction %s not found", txid)
+	}
+
+	return TxResult{NewTxView(tx), hex.EncodeToString(block.Hash), block.Height, s.bc.GetBestHeight() - block.Height + 1}, nil
+}
+
+func (s *RESTServer) addressUTXOs(address string) (interface{}, error) {
+	if !ValidateAddress(address) {
+		return nil, restBadRequest("invalid address '%s'", address)
+	}
+	pubKeyHash, _, _ := base58.CheckDecode(address)
+
+	UTXOs := []UTXOView{}
+	for _, u := range s.bc.FindSpendableOutputs(pubKeyHash) {
+		UTXOs = append(UTXOs, NewUTXOView(u))
+	}
+
+	return UTXOs, nil
+}
+
+// FindUTXO 의 합으로 잔액을 구함
+func (s *RESTServer) addressBalance(address string) (interface{}, error) {
+	if !ValidateAddress(address) {
+		return nil, restBadRequest("invalid address '%s'", address)
+	}
+
+	return BalanceView{address, s.bc.GetBalance(address)}, nil
+}
@@ -0,0 +1,17 @@
+package main
+
+// 23. REST 서버는 읽기 전용으로 연 Blockchain 하나로 모든 요청을 처리
+type RESTServer struct {
+	bc *Blockchain
+}
+
+// 오류 응답
+type restError struct {
+	Error string `json:"error"`
+}
+
+// /address/{addr}/balance 의 응답
+type BalanceView struct {
+	Address string `json:"address"`
+	Balance uint64 `json:"balance"`
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+// 22. JSON-RPC 서버
+// 지금까지는 os.Args 를 해석하는 CLI.Run() 이 유일한 인터페이스였기 때문에 다른 서비스에서 조회하거나 거래를 보낼 수 없었음
+// localhost 에서 HTTP 로 JSON-RPC 요청을 받아 Blockchain, KeyStore, 트랜잭션 기능에 연결
+//   - 모든 요청은 Basic 인증이 필요
+//   - sendtoaddress, sendrawtransaction 으로 받은 트랜잭션은 메모리풀에 모아두었다가 mineInterval 마다 채굴
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcMiscError      = -1
+)
+
+// RPC 메서드, params 를 해석하여 결과를 반환
+type rpcHandler func(s *RPCServer, params []json.RawMessage) (interface{}, error)
+
+var rpcHandlers map[string]rpcHandler
+
+func init() {
+	rpcHandlers = map[string]rpcHandler{
+		"getblockcount":      (*RPCServer).getBlockCount,
+		"getchaintips":       (*RPCServer).getChainTips,
+		"invalidateblock":    (*RPCServer).invalidateBlock,
+		"getblock":           (*RPCServer).getBlock,
+		"gettransaction":     (*RPCServer).getTransaction,
+		"getbalance":         (*RPCServer).getBalance,
+		"listunspent":        (*RPCServer).listUnspent,
+		"sendtoaddress":      (*RPCServer).sendToAddress,
+		"getnewaddress":      (*RPCServer).getNewAddress,
+		"sendrawtransaction": (*RPCServer).sendRawTransaction,
+		"getmempoolinfo":     (*RPCServer).getMempoolInfo,
+		"generate":           (*RPCServer).generate,
+		"setmocktime":        (*RPCServer).setMockTime,
+	}
+}
+
+// 잘못된 파라메타를 나타내는 오류, rpcInvalidParams 코드로 응답
+type rpcParamError struct{ error }
+
+func NewRPCServer(bc *Blockchain, user, password, minerAddress string) *RPCServer {
+	return &RPCServer{bc: bc, mempool: NewMempool(), user: user, password: password, minerAddress: minerAddress, mu: new(sync.RWMutex)}
+}
+
+// localhost:port 에서 요청을 받기 시작
+// mineInterval 이 0 보다 크면 그 주기마다 메모리풀의 트랜잭션들을 채굴
+func (s *RPCServer) ListenAndServe(port int, mineInterval time.Duration) error {
+	if mineInterval > 0 {
+		go s.mineLoop(mineInterval)
+	}
+
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	log.Printf("RPC server listening on %s", addr)
+
+	return http.ListenAndServe(addr, s)
+}
+
+// 메모리풀에 트랜잭션이 있으면 주기적으로 채굴
+// 34) minerAddress 가 있다면 메모리풀이 비어 있어도 코인베이스만 담은 블록을 채굴하여 보상을 지급
+func (s *RPCServer) mineLoop(interval time.Duration) {
+	for range time.Tick(interval) {
+		txs := s.mempool.Transactions()
+		if len(txs) == 0 && s.minerAddress == "" {
+			continue
+		}
+
+		s.mu.Lock()
+		block, err := s.bc.MineBlock(txs, s.minerAddress)
+		if err != nil {
+			s.mempool.Evict(s.bc, err)
+			s.mu.Unlock()
+			log.Printf("Mining failed: %v", err)
+			continue
+		}
+		s.mempool.Update(s.bc)
+		s.mu.Unlock()
+
+		log.Printf("Mined block %d %x with %d transactions", block.Height, block.Hash, len(txs))
+	}
+}
+
+// HTTP 요청 처리, Basic 인증을 확인한 뒤 JSON-RPC 요청을 해석하여 메서드를 호출
+func (s *RPCServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, password, ok := r.BasicAuth()
+	if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(s.user)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(password), []byte(s.password)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="stbc"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "JSON-RPC requests must be POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req rpcRequest
+	var resp rpcResponse
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp.Error = &rpcError{rpcParseError, err.Error()}
+	} else {
+		resp.ID = req.ID
+		resp.Result, resp.Error = s.call(req.Method, req.Params)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// 메서드를 호출하기 위한 메서드
+// 블록체인 코드는 오류를 log.Panic 으로 처리하는 곳이 많기 때문에 panic 을 오류 응답으로 바꿈
+func (s *RPCServer) call(method string, params []json.RawMessage) (result interface{}, rerr *rpcError) {
+	handler, ok := rpcHandlers[method]
+	if !ok {
+		return nil, &rpcError{rpcMethodNotFound, fmt.Sprintf("method '%s' not found", method)}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, rerr = nil, &rpcError{rpcMiscError, fmt.Sprint(r)}
+		}
+	}()
+
+	result, err := handler(s, params)
+	if err != nil {
+		var paramErr rpcParamError
+		if errors.As(err, &paramErr) {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		return nil, &rpcError{rpcMiscError, err.Error()}
+	}
+
+	return result, nil
+}
+
+// i 번째 파라메타를 v 로 해석, 파라메타가 없고 required 가 아니라면 v 를 그대로 둠
+func rpcParam(params []json.RawMessage, i int, required bool, v interface{}) error {
+	if i >= len(params) {
+		if required {
+			return rpcParamError{fmt.Errorf("missing parameter %d", i+1)}
+		}
+		return nil
+	}
+	if err := json.Unmarshal(params[i], v); err != nil {
+		return rpcParamError{fmt.Errorf("invalid parameter %d: %v", i+1, err)}
+	}
+
+	return nil
+}
+
+func rpcAddressParam(params []json.RawMessage, i int) (string, []byte, error) {
+	var address string
+	if err := rpcParam(params, i, true, &address); err != nil {
+		return "", nil, err
+	}
+	if !ValidateAddress(address) {
+		return "", nil, rpcParamError{fmt.Errorf("invalid address '%s'", address)}
+	}
+	pubKeyHash, _, _ := base58.CheckDecode(address)
+
+	return address, pubKeyHash, nil
+}
+
+func (s *RPCServer) getBlockCount(params []json.RawMessage) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.bc.GetBestHeight(), nil
+}
+
+func (s *RPCServer) getChainTips(params []json.RawMessage) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.bc.GetChainTips(), nil
+}
+
+// 27. invalidateblock "hash"
+// 메인 체인에서 해제된 블록들의 트랜잭션은 메모리풀로 되돌림
+func (s *RPCServer) invalidateBlock(params []json.RawMessage) (interface{}, error) {
+	var param string
+	if err := rpcParam(params, 0, true, &param); err != nil {
+		return nil, err
+	}
+	hash, err := hex.DecodeString(param)
+	if err != nil {
+		return nil, rpcParamError{fmt.Errorf("invalid block hash '%s'", param)}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	disconnected, err := s.bc.InvalidateBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	s.mempool.AddDisconnected(s.bc, disconnected)
+
+	return nil, nil
+}
+
+// getblock "hash" 또는 getblock height
+func (s *RPCServer) getBlock(params []json.RawMessage) (interface{}, error) {
+	var param interface{}
+	if err := rpcParam(params, 0, true, &param); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var block *Block
+	var err error
+	switch p := param.(type) {
+	case float64:
+		block, err = s.bc.GetBlockByHeight(int(p))
+	case string:
+		hash, decodeErr := hex.DecodeString(p)
+		if decodeErr != nil {
+			return nil, rpcParamError{fmt.Errorf("invalid block hash '%s'", p)}
+		}
+		block, err = s.bc.GetBlock(hash)
+	default:
+		return nil, rpcParamError{errors.New("expected block hash or height")}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBlockView(block), nil
+}
+
+func (s *RPCServer) getTransaction(params []json.RawMessage) (interface{}, error) {
+	var txid string
+	if err := rpcParam(params, 0, true, &txid); err != nil {
+		return nil, err
+	}
+	id, err := hex.DecodeString(txid)
+	if err != nil {
+		return nil, rpcParamError{fmt.Errorf("invalid txid '%s'", txid)}
+	}
+
+	if tx := s.mempool.Get(id); tx != nil {
+		return TxResult{TxView: NewTxView(tx), Height: -1}, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tx, block := s.bc.FindTransactionBlock(id)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %s not found", txid)
+	}
+
+	return TxResult{NewTxView(tx), hex.EncodeToString(block.Hash), block.Height, s.bc.GetBestHeight() - block.Height + 1}, nil
+}
+
+func (s *RPCServer) getBalance(params []json.RawMessage) (interface{}, error) {
+	address, _, err := rpcAddressParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.bc.GetBalance(address), nil
+}
+
+func (s *RPCServer) listUnspent(params []json.RawMessage) (interface{}, error) {
+	_, pubKeyHash, err := rpcAddressParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	UTXOs := []UTXOView{}
+	for _, u := range s.bc.FindSpendableOutputs(pubKeyHash) {
+		UTXOs = append(UTXOs, NewUTXOView(u))
+	}
+
+	return UTXOs, nil
+}
+
+// sendtoaddress "address" amount ["from", ...]
+// from 을 지정하지 않으면 키스토어의 (감시 전용이 아닌) 모든 지갑에서 보내며, 잔액은 첫 번째 from 주소로 보냄
+func (s *RPCServer) sendToAddress(params []json.RawMessage) (interface{}, error) {
+	address, _, err := rpcAddressParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	var amount uint64
+	if err := rpcParam(params, 1, true, &amount); err != nil {
+		return nil, err
+	}
+	if amount == 0 {
+		return nil, rpcParamError{errors.New("amount must be positive")}
+	}
+	var from []string
+	if err := rpcParam(params, 2, false, &from); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keyStore := NewKeyStore()
+	pubKeys := make(map[string][]byte)
+	var wallets []string
+	for addr, wallet := range keyStore.Wallets {
+		pubKeys[addr] = wallet.PubKey
+		if !wallet.IsWatchOnly() {
+			wallets = append(wallets, addr)
+		}
+	}
+	if len(from) == 0 {
+		sort.Strings(wallets)
+		from = wallets
+	}
+
+	tx, err := s.bc.CreateTransaction(from, pubKeys, []Recipient{{address, amount}}, "", LargestFirst{}, nil, s.mempool.SpentOutpoints())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.bc.SignTransaction(keyStore.FindKey, tx); err != nil {
+		return nil, err
+	}
+	if err := s.mempool.Add(s.bc, tx); err != nil {
+		return nil, err
+	}
+	if s.relay != nil {
+		s.relay(tx)
+	}
+
+	return hex.EncodeToString(tx.ID), nil
+}
+
+func (s *RPCServer) getNewAddress(params []json.RawMessage) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return NewKeyStore().CreateWallet().GetAddress(), nil
+}
+
+// sendrawtransaction "hex", hex 는 createrawtx/signrawtx 의 RawTransaction 형식
+func (s *RPCServer) sendRawTransaction(params []json.RawMessage) (interface{}, error) {
+	var encoded string
+	if err := rpcParam(params, 0, true, &encoded); err != nil {
+		return nil, err
+	}
+	raw, err := DecodeRawTransaction(encoded)
+	if err != nil {
+		return nil, rpcParamError{err}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.mempool.Add(s.bc, raw.Tx); err != nil {
+		return nil, err
+	}
+	if s.relay != nil {
+		s.relay(raw.Tx)
+	}
+
+	return hex.EncodeToString(raw.Tx.ID), nil
+}
+
+func (s *RPCServer) getMempoolInfo(params []json.RawMessage) (interface{}, error) {
+	return s.mempool.Info(), nil
+}
+
+// RPC 클라이언트, 파라메타는 json 으로 해석되지 않으면 문자열로 보냄
+func CallRPC(url, user, password, method string, args []string) (json.RawMessage, error) {
+	params := []interface{}{}
+	for _, arg := range args {
+		var v interface{}
+		if err := json.Unmarshal([]byte(arg), &v); err != nil {
+			v = arg
+		}
+		params = append(params, v)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "1.0", "id": strconv.FormatInt(time.Now().UnixNano(), 10), "method": method, "params": params})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(user, password)
+	req.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode == http.StatusUnauthorized {
+		return nil, errors.New("RPC authentication failed")
+	}
+
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("invalid RPC response (HTTP %d): %v", httpResp.StatusCode, err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("RPC error %d: %s", resp.Error.Code, resp.Error.Message)
+	}
+
+	return resp.Result, nil
+}
+
+// 35. generate n "address", regtest 에서 address 에게 보상을 주는 블록 n 개를 채굴하고 블록해시들을 반환
+// 메모리풀의 트랜잭션은 담지 않음
+func (s *RPCServer) generate(params []json.RawMessage) (interface{}, error) {
+	var n int
+	if err := rpcParam(params, 0, true, &n); err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, rpcParamError{errors.New("number of blocks must be positive")}
+	}
+	address, _, err := rpcAddressParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	blocks, err := s.bc.Generate(n, address)
+	if err != nil {
+		return nil, err
+	}
+	s.mempool.Update(s.bc)
+
+	hashes := make([]string, len(blocks))
+	for i, block := range blocks {
+		hashes[i] = hex.EncodeToString(block.Hash)
+	}
+
+	return hashes, nil
+}
+
+// 35. setmocktime timestamp, regtest 에서 새 블록의 시간을 고정하며 0 이면 현재 시간으로 되돌림
+func (s *RPCServer) setMockTime(params []json.RawMessage) (interface{}, error) {
+	var t int64
+	if err := rpcParam(params, 0, true, &t); err != nil {
+		return nil, err
+	}
+
+	if err := SetMockTime(t); err != nil {
+		return nil, rpcParamError{err}
+	}
+
+	return nil, nil
+}
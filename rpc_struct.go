@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// JSON-RPC 요청, params 는 위치 기반 배열
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc,omitempty"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+// JSON-RPC 응답, 성공하면 Result 를 실패하면 Error 를 가짐
+type rpcResponse struct {
+	Result interface{}     `json:"result"`
+	Error  *rpcError       `json:"error"`
+	ID     json.RawMessage `json:"id"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// RPC 서버는 하나의 Blockchain 과 Mempool 을 가지고 요청을 처리
+// mu 는 블록체인을 변경하는 작업(채굴, 지갑 생성)과 조회를 구분하기 위해 사용
+// 25) 노드와 함께 실행될 때는 노드의 mu 를 공유하고, 메모리풀에 추가한 트랜잭션을 relay 로 다른 노드에게 전파
+type RPCServer struct {
+	bc           *Blockchain
+	mempool      *Mempool
+	user         string
+	password     string
+	minerAddress string
+	mu           *sync.RWMutex
+	relay        func(tx *Transaction)
+}
+
+// gettransaction 의 결과, 메모리풀에 있는 트랜잭션은 Confirmations 가 0
+type TxResult struct {
+	TxView
+	BlockHash     string `json:"blockhash,omitempty"`
+	Height        int    `json:"height"`
+	Confirmations int    `json:"confirmations"`
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testRPCUser     = "user"
+	testRPCPassword = "password"
+)
+
+// RPC 서버에 요청을 보내고 응답을 받기 위한 테스트 함수
+func rpcTestCall(t *testing.T, s *RPCServer, method string, params ...interface{}) rpcResponse {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]interface{}{"id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body)))
+	r.SetBasicAuth(testRPCUser, testRPCPassword)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: status %d", method, w.Code)
+	}
+
+	var resp rpcResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	return resp
+}
+
+// 인증 정보가 틀리거나 POST 가 아닌 요청은 메서드를 호출하지 않음
+func TestRPCAuth(t *testing.T) {
+	s := NewRPCServer(newTestBlockchain(t), testRPCUser, testRPCPassword, "")
+
+	tests := []struct {
+		name     string
+		method   string
+		user     string
+		password string
+		auth     bool
+		status   int
+	}{
+		{"no auth", http.MethodPost, "", "", false, http.StatusUnauthorized},
+		{"wrong user", http.MethodPost, "other", testRPCPassword, true, http.StatusUnauthorized},
+		{"wrong password", http.MethodPost, testRPCUser, "other", true, http.StatusUnauthorized},
+		{"get", http.MethodGet, testRPCUser, testRPCPassword, true, http.StatusMethodNotAllowed},
+		{"ok", http.MethodPost, testRPCUser, testRPCPassword, true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", strings.NewReader(`{"id":1,"method":"getblockcount","params":[]}`))
+			if tt.auth {
+				r.SetBasicAuth(tt.user, tt.password)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+// 알 수 없는 메서드, 잘못된 파라메타, 잘못된 요청은 각각의 오류 코드로 응답
+func TestRPCErrors(t *testing.T) {
+	s := NewRPCServer(newTestBlockchain(t), testRPCUser, testRPCPassword, "")
+
+	if resp := rpcTestCall(t, s, "nosuchmethod"); resp.Error == nil || resp.Error.Code != rpcMethodNotFound {
+		t.Fatalf("error = %v, want code %d", resp.Error, rpcMethodNotFound)
+	}
+	if resp := rpcTestCall(t, s, "getbalance", "not an address"); resp.Error == nil || resp.Error.Code != rpcInvalidParams {
+		t.Fatalf("error = %v, want code %d", resp.Error, rpcInvalidParams)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{"))
+	r.SetBasicAuth(testRPCUser, testRPCPassword)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	var resp rpcResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == nil || resp.Error.Code != rpcParseError {
+		t.Fatalf("error = %v, want code %d", resp.Error, rpcParseError)
+	}
+}
+
+// sendrawtransaction 은 검증된 트랜잭션만 메모리풀에 넣고, ID 가 위조되었거나 다른 사람의 출력을 사용하는 트랜잭션은 거부
+func TestRPCSendRawTransaction(t *testing.T) {
+	bc := newTestBlockchain(t)
+	s := NewRPCServer(bc, testRPCUser, testRPCPassword, "")
+	wallet, attacker := NewWallet(), NewWallet()
+	blocks, err := bc.Generate(1, wallet.GetAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := newTestTransaction(t, bc, wallet, wallet, newTestAddress(), 1)
+
+	encode := func(tx *Transaction) string {
+		raw, err := bc.NewRawTransaction(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw.Encode()
+	}
+	forged := *tx
+	forged.ID = blocks[0].Transactions[0].ID
+
+	tests := []struct {
+		name string
+		tx   *Transaction
+	}{
+		{"forged id", &forged},
+		{"foreign output", newTestTransaction(t, bc, wallet, attacker, attacker.GetAddress(), 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := rpcTestCall(t, s, "sendrawtransaction", encode(tt.tx)); resp.Error == nil {
+				t.Fatal("sendrawtransaction accepted an invalid transaction")
+			}
+			if info := s.mempool.Info(); info.Size != 0 {
+				t.Fatalf("mempool has %d transactions, want 0", info.Size)
+			}
+		})
+	}
+
+	resp := rpcTestCall(t, s, "sendrawtransaction", encode(tx))
+	if resp.Error != nil {
+		t.Fatal(resp.Error.Message)
+	}
+	if resp.Result != hex.EncodeToString(tx.ID) {
+		t.Fatalf("result = %v, want %x", resp.Result, tx.ID)
+	}
+	if info := s.mempool.Info(); info.Size != 1 || info.Txids[0] != hex.EncodeToString(tx.ID) {
+		t.Fatalf("mempool = %v, want %x", info.Txids, tx.ID)
+	}
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
-	"bytes"
+	"errors"
+	"fmt"
 	"log"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
 )
 
 // 거래위한 페이지
@@ -15,48 +18,117 @@ import (
 //		- 지갑간의 주소를 통한 거래를 위해 KeyStore 를 이곳에서 사용하도록 변경
 //		- 공개키해시를 사용하여 검증
 //		- NewTXOutput() 메서드를 사용하여 출력을 구성
+//
+//  13. 코인 선택 기능으로 인한 변경점
+//		- UTXO 를 순회 순서대로 사용하지 않고 selector 전략으로 선택
+//		- pinned 로 지정한 UTXO 를 먼저 사용
+//		- 잔액이 먼지(DustThreshold)보다 작으면 먼지만큼 더 골라 잔액 출력을 만들고, 더 고를 수 없다면 ErrDustChange
+//
+//  14. 다수의 수신자 기능으로 인한 변경점
+//		- value, to 대신 recipients 를 받아 수신자마다 NewTXOutput() 으로 출력을 구성하고, 잔액 출력은 마지막에 추가
+//		- 서명은 SignTransaction() 으로 한 번만 수행
+//
+//  15. 다수의 지갑으로 거래하는 기능으로 인한 변경점
+//		- from 의 모든 지갑의 UTXO 를 모아 코인을 선택하고, 입력마다 해당 지갑의 공개키를 넣음
+//		- 서명은 입력마다 KeyStore.FindKey() 로 찾은 개인키로 수행
+//		- 잔액은 change 주소로 보내며, 지정하지 않으면 from 의 첫 번째 주소로 보냄
+
+func (bc *Blockchain) Send(from []string, recipients []Recipient, change string, selector CoinSelector, pinned []Outpoint) *Transaction {
+	keyStore := NewKeyStore()
+
+	pubKeys := make(map[string][]byte)
+	for _, address := range from {
+		wallet := keyStore.Wallets[address]
+		if wallet == nil {
+			log.Panicf("ERROR: Wallet '%s' not found", address)
+		}
+		pubKeys[address] = wallet.PubKey
+	}
 
-func (bc *Blockchain) Send(value uint64, from, to string) *Transaction {
+	tx, err := bc.CreateTransaction(from, pubKeys, recipients, change, selector, pinned, nil)
+	if err != nil {
+		log.Panic("ERROR: ", err)
+	}
+
+	if err := bc.SignTransaction(keyStore.FindKey, tx); err != nil {
+		log.Panic("ERROR: ", err)
+	}
+
+	return tx
+}
+
+// 16. 오프라인 서명 기능을 위해 Send() 에서 분리한 메서드
+// 서명되지 않은 거래를 만들기 위한 메서드로, 개인키 없이 주소만으로 거래를 구성할 수 있음
+// pubKeys 에 주소의 공개키가 있다면 입력에 넣고, 없다면 서명할 때 넣음
+// 22) exclude 에 있는 출력(txid:idx)은 사용하지 않음(메모리풀의 트랜잭션이 이미 사용한 출력)
+func (bc *Blockchain) CreateTransaction(from []string, pubKeys map[string][]byte, recipients []Recipient, change string, selector CoinSelector, pinned []Outpoint, exclude map[string]bool) (*Transaction, error) {
 	var txin []TXInput
 	var txout []TXOutput
-	keyStore := NewKeyStore()
 
-	wallet := keyStore.Wallets[from]
-	UTXs := bc.FindUnspentTransactions(HashPubKey(wallet.PubKey))
-	var acc uint64
+	if len(from) == 0 {
+		return nil, fmt.Errorf("no address to spend from")
+	}
 
-Work:
-	for _, tx := range UTXs {
-		for outIdx, out := range tx.Vout {
-			// if out.ScriptPubKey == from && acc < value {
-			// 	acc += out.Value
-			// 	txin = append(txin, TXInput{tx.ID, outIdx, from})
-			// }
-			if bytes.Compare(out.PubKeyHash, HashPubKey(wallet.PubKey)) == 0 && acc < value {
-				acc += out.Value
-				txin = append(txin, TXInput{tx.ID, outIdx, nil, wallet.PubKey})
-			}
-			if acc >= value {
-				break Work
+	var UTXOs []UTXO
+	seen := make(map[string]bool)
+	for _, address := range from {
+		if !ValidateAddress(address) {
+			return nil, fmt.Errorf("invalid address '%s'", address)
+		}
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+
+		pubKeyHash, _, _ := base58.CheckDecode(address)
+		for _, u := range bc.FindSpendableOutputs(pubKeyHash) {
+			if !exclude[u.String()] {
+				UTXOs = append(UTXOs, u)
 			}
 		}
 	}
 
-	if value > acc {
-		log.Panic("ERROR: NOT enough funds")
+	if change == "" {
+		change = from[0]
+	}
+	if !ValidateAddress(change) {
+		return nil, fmt.Errorf("invalid change address '%s'", change)
 	}
 
-	// txout = append(txout, TXOutput{value, to})
-	// if acc > value {
-	// 	txout = append(txout, TXOutput{acc - value, from})
-	// }
-	txout = append(txout, *NewTXOutput(value, to))
-	if acc > value {
-		txout = append(txout, *NewTXOutput(acc-value, from))
+	value, err := TotalAmount(recipients)
+	if err != nil {
+		return nil, err
 	}
 
-	tx := NewTransaction(txin, txout)
-	bc.SignTransaction(wallet.PrivKey, tx)
+	dust := DustThreshold(bc.GetBestHeight() + 1)
+	selected, err := SelectCoins(UTXOs, value, selector, pinned, dust)
+	if err != nil {
+		return nil, err
+	}
+	// 먼지인 잔액은 출력을 만들지 않고 수수료로 버리게 되므로, 먼지만큼 더 골라 잔액 출력을 만들 수 있게 함
+	if remainder := sumUTXO(selected) - value; remainder > 0 && remainder < dust {
+		selected, err = SelectCoins(UTXOs, value+dust, selector, pinned, dust)
+		if errors.Is(err, ErrInsufficientFunds) {
+			return nil, fmt.Errorf("%w: change %d is below the dust threshold %d and would be paid as fee, send %d instead", ErrDustChange, remainder, dust, value+remainder)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	return tx
+	var acc uint64
+	for _, u := range selected {
+		acc += u.Output.Value
+		pubKey := pubKeys[base58.CheckEncode(u.Output.PubKeyHash, netParams.AddressVersion)]
+		txin = append(txin, TXInput{u.Txid, u.Vout, nil, pubKey})
+	}
+
+	for _, r := range recipients {
+		txout = append(txout, *NewTXOutput(r.Amount, r.Address))
+	}
+	if remainder := acc - value; remainder > 0 {
+		txout = append(txout, *NewTXOutput(remainder, change))
+	}
+
+	return NewTransaction(txin, txout), nil
 }
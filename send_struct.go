@@ -0,0 +1,7 @@
+package main
+
+// 하나의 거래에서 돈을 받을 수신자와 금액
+type Recipient struct {
+	Address string `json:"address"`
+	Amount  uint64 `json:"amount"`
+}
@@ -6,7 +6,7 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"encoding/hex"
-	"log"
+	"fmt"
 	"math/big"
 )
 
@@ -20,31 +20,61 @@ import (
 // 서명을 위한 데이터는 송신자와 수신자의 식별정보를 사용하며, 공개키 해시(Public Key Hash)로 표현
 // 이를위해, 거래를 바로 해싱하지 않고 거래를 복사한 뒤 값을 일부 수정하여 해싱
 // ECDSA 알고리즘 사용
-func (tx *Transaction) Sign(privKey *ecdsa.PrivateKey, prevTXs map[string]*Transaction) {
+//
+// 15. 다수의 지갑으로 거래하는 기능으로 인한 변경점
+//   - 하나의 개인키 대신 입력마다 참조하는 출력의 공개키 해시로 개인키를 찾아(lookup) 서명
+//   - 개인키를 찾지 못하면 서명하지 않고 오류를 반환
+//
+// 16. 오프라인 서명 기능으로 인한 변경점
+//   - 공개키 없이 만들어진 트랜잭션(createrawtx)의 입력에는 서명할 때 개인키의 공개키를 넣고 트랜잭션 ID 를 다시 구함
+func (tx *Transaction) Sign(lookup KeyLookup, prevTXs map[string]*Transaction) error {
 	if tx.IsCoinbase() {
-		return
+		return nil
 	}
 	// 거래의 복사본 생성
 	txCopy := tx.TrimmedCopy()
+	filled := false
 
 	for inID, in := range txCopy.Vin {
+		prevTX := prevTXs[hex.EncodeToString(in.Txid)]
+		if prevTX == nil || in.Vout < 0 || in.Vout >= len(prevTX.Vout) {
+			return fmt.Errorf("previous output %x:%d not found", in.Txid, in.Vout)
+		}
+
+		pubKeyHash := prevTX.Vout[in.Vout].PubKeyHash
+		privKey, err := lookup(pubKeyHash)
+		if err != nil {
+			return err
+		}
+
 		// 서명 대상 데이터 구성 및 초기화, 데이터를 대상으로 해싱 .SetID()
 		txCopy.Vin[inID].Signature = nil
-		txCopy.Vin[inID].PubKey = prevTXs[hex.EncodeToString(in.Txid)].Vout[in.Vout].PubKeyHash
+		txCopy.Vin[inID].PubKey = pubKeyHash
 		txCopy.SetID()
 		txCopy.Vin[inID].PubKey = nil
 
 		// 서명 생성, 개인키와 서명한 데이터의 해시를 넣자.
 		r, s, err := ecdsa.Sign(rand.Reader, privKey, txCopy.ID)
 		if err != nil {
-			log.Panic(err)
+			return err
 		}
 
 		signature := append(r.Bytes(), s.Bytes()...)
 		tx.Vin[inID].Signature = signature
 
 		// tx.Vin[inID].Signature = append(r.Bytes(), s.Bytes()...)
+
+		if len(tx.Vin[inID].PubKey) == 0 {
+			tx.Vin[inID].PubKey = append(privKey.PublicKey.X.Bytes(), privKey.PublicKey.Y.Bytes()...)
+			filled = true
+		}
 	}
+
+	if filled {
+		tx.ID = tx.Hash()
+	}
+
+	return nil
 }
 
 // 대상 트랜잭션의 복사본을 생성을 위한 메서드
@@ -65,15 +95,42 @@ func (tx *Transaction) TrimmedCopy() *Transaction {
 // 서명 검증을 위한 메서드
 // 서명을 검증하기 위해서는 해시된 데이터, 서명(R,S), 공개키(X,Y)가 필요하며, 파라매터로는 .Sign() 과 마찬가지로 이전 트랜잭션들을 받음
 // 검증을 위해 서명에 사용된 데이터를 해시해서 비교
+// 22) 서명은 입력에 넣은 공개키의 주인이 서명했다는 것만 증명하므로, 그 공개키의 해시가 참조하는 출력의 공개키 해시와 같아야 함
+//   - 다르다면 다른 사람의 출력을 자신의 키로 서명하여 사용하는 것이므로 false
+//   - 이전 트랜잭션이 없거나 출력 인덱스가 범위를 벗어나도 false
 func (tx *Transaction) Verify(prevTXs map[string]*Transaction) bool {
+	prevOuts := make(map[string]TXOutput)
+	for _, in := range tx.Vin {
+		prevTX := prevTXs[hex.EncodeToString(in.Txid)]
+		if prevTX == nil || in.Vout < 0 || in.Vout >= len(prevTX.Vout) {
+			return false
+		}
+		prevOuts[Outpoint{in.Txid, in.Vout}.String()] = prevTX.Vout[in.Vout]
+	}
+
+	return tx.verifyOutputs(prevOuts)
+}
+
+// 26) 이전 트랜잭션 대신 입력이 참조하는 출력들(키는 txid:idx)로 서명을 검증하기 위한 메서드
+// ValidateTransaction() 에서 블록체인을 순회하지 않고 UTXO 집합에서 찾은 출력들로 검증하기 위해 Verify() 에서 분리
+func (tx *Transaction) verifyOutputs(prevOuts map[string]TXOutput) bool {
 	txCopy := tx.TrimmedCopy()
 	curve := elliptic.P256()
 
 	for inID, in := range tx.Vin {
+		prevOut, ok := prevOuts[Outpoint{in.Txid, in.Vout}.String()]
+		if !ok {
+			return false
+		}
+		pubKeyHash := prevOut.PubKeyHash
+		if len(in.Signature) == 0 || !bytes.Equal(HashPubKey(in.PubKey), pubKeyHash) {
+			return false
+		}
+
 		// 서명에 사용할 데이터를 생성하고 해싱.
 		// 여기서 생성된 해시는 검증을 위해 만든 것.
 		txCopy.Vin[inID].Signature = nil
-		txCopy.Vin[inID].PubKey = prevTXs[hex.EncodeToString(in.Txid)].Vout[in.Vout].PubKeyHash
+		txCopy.Vin[inID].PubKey = pubKeyHash
 		txCopy.SetID()
 		txCopy.Vin[inID].PubKey = nil
 
@@ -119,19 +176,40 @@ func (bc *Blockchain) FindTransaction(txid []byte) *Transaction {
 	return nil
 }
 
+// 22. 트랜잭션과 그 트랜잭션이 담긴 블록을 함께 찾기 위한 메서드
+func (bc *Blockchain) FindTransactionBlock(txid []byte) (*Transaction, *Block) {
+	bci := NewBlockchainIterator(bc)
+	for bci.HasNext() {
+		block := bci.Next()
+		for _, tx := range block.Transactions {
+			if bytes.Compare(tx.ID, txid) == 0 {
+				return tx, block
+			}
+		}
+	}
+
+	return nil, nil
+}
+
 // 트랜잭션에 서명을 하기 위한 메서드
-func (bc *Blockchain) SignTransaction(privKey *ecdsa.PrivateKey, tx *Transaction) {
+// 15) 입력마다 lookup 으로 찾은 개인키로 서명하도록 변경
+func (bc *Blockchain) SignTransaction(lookup KeyLookup, tx *Transaction) error {
 	prevTXs := make(map[string]*Transaction)
 
 	for _, in := range tx.Vin {
 		prevTXs[hex.EncodeToString(in.Txid)] = bc.FindTransaction(in.Txid)
 	}
 
-	tx.Sign(privKey, prevTXs)
+	return tx.Sign(lookup, prevTXs)
 }
 
 // 해당 트랜잭션의 서명을 검증하기 위한 메서드
+// 코인베이스 트랜잭션은 서명할 입력이 없으므로 검증하지 않음(보상 금액은 ValidateCoinbase()에서 검증)
 func (bc *Blockchain) VerifyTransaction(tx *Transaction) bool {
+	if tx.IsCoinbase() {
+		return true
+	}
+
 	prevTXs := make(map[string]*Transaction)
 
 	for _, in := range tx.Vin {
@@ -140,3 +218,55 @@ func (bc *Blockchain) VerifyTransaction(tx *Transaction) bool {
 
 	return tx.Verify(prevTXs)
 }
+
+// 블록이나 메모리풀에 추가하기 전에 트랜잭션을 검증하기 위한 메서드
+//   - 모든 입력이 서명되어 있어야 함
+//   - 입력이 참조하는 출력은 블록체인에 존재하고 소비되지 않았어야 하며, 같은 출력을 두 번 사용할 수 없음
+//   - 입력의 공개키는 참조하는 출력의 공개키 해시와 일치해야 함(출력의 주인만 사용 가능)
+//   - 서명이 올바르고 출력의 합이 입력의 합을 넘지 않아야 함
+//
+// 16) 트랜잭션 ID 는 내용의 해시(Hash)와 같아야 함, 받은 트랜잭션(sendrawtx)의 ID 를 믿고 채굴하면 다른 노드가 블록을 거부함
+// 26) 모든 UTXO(FindAllUTXO)나 이전 트랜잭션(FindTransaction)을 찾지 않고 입력이 참조하는 출력만 UTXO 집합에서 찾음(findInputOutputs)
+func (bc *Blockchain) ValidateTransaction(tx *Transaction) error {
+	if len(tx.Vin) == 0 || len(tx.Vout) == 0 {
+		return fmt.Errorf("transaction %x has no inputs or outputs", tx.ID)
+	}
+	if !bytes.Equal(tx.ID, tx.Hash()) {
+		return fmt.Errorf("transaction %x has an invalid id", tx.ID)
+	}
+	if tx.IsCoinbase() {
+		return fmt.Errorf("transaction %x is a coinbase", tx.ID)
+	}
+	for _, in := range tx.Vin {
+		if len(in.Signature) == 0 || len(in.PubKey) == 0 {
+			return fmt.Errorf("transaction %x is not fully signed", tx.ID)
+		}
+	}
+
+	prevOuts := bc.findInputOutputs(tx.Vin)
+	spent := make(map[string]bool)
+	for _, in := range tx.Vin {
+		outpoint := Outpoint{in.Txid, in.Vout}.String()
+		if spent[outpoint] {
+			return fmt.Errorf("output %s is spent twice", outpoint)
+		}
+		spent[outpoint] = true
+
+		out, ok := prevOuts[outpoint]
+		if !ok {
+			return fmt.Errorf("output %s is already spent or does not exist", outpoint)
+		}
+		if !bytes.Equal(HashPubKey(in.PubKey), out.PubKeyHash) {
+			return fmt.Errorf("input %s of transaction %x is not signed by the owner of the output", outpoint, tx.ID)
+		}
+	}
+
+	if !tx.verifyOutputs(prevOuts) {
+		return fmt.Errorf("transaction %x has an invalid signature", tx.ID)
+	}
+	if _, err := transactionFee(tx, prevOuts); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// 다른 사람의 출력을 자신의 공개키와 개인키로 서명한 트랜잭션은 메모리풀과 블록에 추가할 수 없음
+func TestSpendForeignOutput(t *testing.T) {
+	bc := newTestBlockchain(t)
+	victim, attacker := NewWallet(), NewWallet()
+	if _, err := bc.Generate(1, victim.GetAddress()); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := newTestTransaction(t, bc, victim, attacker, attacker.GetAddress(), GetBlockSubsidy(1))
+	if !bytes.Equal(tx.Vin[0].PubKey, attacker.PubKey) {
+		t.Fatal("input does not carry the attacker's public key")
+	}
+
+	if err := bc.ValidateTransaction(tx); err == nil {
+		t.Fatal("ValidateTransaction accepted a transaction spending a foreign output")
+	}
+	if err := NewMempool().Add(bc, tx); err == nil {
+		t.Fatal("mempool accepted a transaction spending a foreign output")
+	}
+	if _, err := bc.AddBlock([]*Transaction{tx}); err == nil {
+		t.Fatal("AddBlock accepted a transaction spending a foreign output")
+	}
+	if bc.VerifyTransaction(tx) {
+		t.Fatal("VerifyTransaction accepted a transaction spending a foreign output")
+	}
+
+	// 주인이 서명한 같은 출력은 사용할 수 있음
+	if err := bc.ValidateTransaction(newTestTransaction(t, bc, victim, victim, attacker.GetAddress(), 1)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 이전 트랜잭션이 없거나 출력 인덱스가 범위를 벗어난 입력은 panic 없이 검증에 실패함
+func TestVerifyMissingOutput(t *testing.T) {
+	bc := newTestBlockchain(t)
+	wallet := NewWallet()
+	if _, err := bc.Generate(1, wallet.GetAddress()); err != nil {
+		t.Fatal(err)
+	}
+	tx := newTestTransaction(t, bc, wallet, wallet, newTestAddress(), 1)
+	prevTX := bc.FindTransaction(tx.Vin[0].Txid)
+	prevTXs := map[string]*Transaction{hex.EncodeToString(prevTX.ID): prevTX}
+
+	tests := []struct {
+		name string
+		txid []byte
+		vout int
+	}{
+		{"missing transaction", bytes.Repeat([]byte{0xab}, 32), tx.Vin[0].Vout},
+		{"vout out of range", prevTX.ID, len(prevTX.Vout)},
+		{"negative vout", prevTX.ID, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bad := *tx
+			bad.Vin = append([]TXInput{}, tx.Vin...)
+			bad.Vin[0].Txid, bad.Vin[0].Vout = tt.txid, tt.vout
+			if bad.Verify(prevTXs) {
+				t.Fatal("verified an input without a previous output")
+			}
+			if err := bc.ValidateTransaction(&bad); err == nil {
+				t.Fatal("ValidateTransaction accepted an input without a previous output")
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// 30. 저장소 선택
+// 전역 옵션 -storage 로 정하며 블록체인(chain)과 경량 클라이언트의 블록 헤더(headers)에 함께 사용
+const (
+	storageBolt    = "bolt"
+	storageLevelDB = "leveldb"
+	storageMemory  = "memory"
+)
+
+var storageBackend = storageBolt
+
+// 31) 다른 프로세스가 저장소를 쓰고 있을 때 기다리는 시간
+const storageTimeout = 5 * time.Second
+
+var (
+	errBucketExists  = errors.New("bucket already exists")
+	errBucketMissing = errors.New("bucket not found")
+	errTxNotWritable = errors.New("tx not writable")
+
+	errStorageReadOnly = errors.New("storage is read-only")
+
+	ErrDatabaseLocked = errors.New("database locked by another process")
+)
+
+// 저장소 이름이 올바른지 확인하기 위한 함수
+func ValidateStorage(backend string) error {
+	switch backend {
+	case storageBolt, storageLevelDB, storageMemory:
+		return nil
+	}
+
+	return fmt.Errorf("unknown storage '%s' (bolt, leveldb, memory)", backend)
+}
+
+// 이름(chain, headers)에 해당하는 저장소를 열기 위한 함수
+// readOnly 라면 변경할 수 없으며, bolt 는 다른 프로세스가 쓰고 있지 않다면 여러 프로세스가 함께 열 수 있음
+// 31) storageTimeout 안에 열지 못하면 ErrDatabaseLocked
+func OpenStorage(name string, readOnly bool) (Storage, error) {
+	switch storageBackend {
+	case storageBolt:
+		return openBoltStorage(storagePath(name), readOnly)
+	case storageLevelDB:
+		return openLevelDBStorage(storagePath(name), readOnly)
+	case storageMemory:
+		if readOnly {
+			return readOnlyStorage{openMemoryStorage(storagePath(name))}, nil
+		}
+		return openMemoryStorage(storagePath(name)), nil
+	}
+
+	return nil, ValidateStorage(storageBackend)
+}
+
+// 32) 저장소를 지우기 위한 함수(reset, new -force)
+// 다른 프로세스가 쓰고 있는 저장소는 지우지 않도록 먼저 열어서 확인하며, 쓰고 있다면 ErrDatabaseLocked
+func RemoveStorage(name string) error {
+	db, err := OpenStorage(name, false)
+	if err != nil {
+		return err
+	}
+	db.Close()
+
+	switch storageBackend {
+	case storageMemory:
+		removeMemoryStorage(storagePath(name))
+		return nil
+	default:
+		return os.RemoveAll(storagePath(name))
+	}
+}
+
+// 저장소가 있는지 확인하기 위한 함수, 없는 저장소를 열면 빈 저장소가 만들어지므로 열기 전에 확인
+func StorageExists(name string) bool {
+	if storageBackend == storageMemory {
+		return memoryStorageExists(storagePath(name))
+	}
+
+	_, err := os.Stat(storagePath(name))
+	return err == nil
+}
+
+// 파일로 저장하는 저장소의 경로, bolt 는 name.db 파일이고 leveldb 는 name.leveldb 디렉터리
+// 메모리 저장소는 이 경로를 저장소를 구분하는 키로 사용
+func storagePath(name string) string {
+	if storageBackend == storageLevelDB {
+		return dataPath(name + ".leveldb")
+	}
+
+	return dataPath(name + ".db")
+}
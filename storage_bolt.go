@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/boltdb/bolt"
+)
+
+// 30. bolt 저장소, 기본 저장소
+// bolt 의 트랜잭션과 버킷을 그대로 사용
+func openBoltStorage(path string, readOnly bool) (Storage, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: storageTimeout, ReadOnly: readOnly})
+	if err == bolt.ErrTimeout {
+		return nil, fmt.Errorf("%s: %w", path, ErrDatabaseLocked)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltStorage{db}, nil
+}
+
+func (s *boltStorage) View(fn func(tx StorageTx) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx})
+	})
+}
+
+func (s *boltStorage) Update(fn func(tx StorageTx) error) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx})
+	})
+	if err == bolt.ErrDatabaseReadOnly {
+		return errStorageReadOnly
+	}
+
+	return err
+}
+
+func (s *boltStorage) Close() error {
+	return s.db.Close()
+}
+
+// *bolt.Bucket 이 nil 일 때 nil 인터페이스를 반환하기 위한 함수
+func wrapBoltBucket(b *bolt.Bucket) StorageBucket {
+	if b == nil {
+		return nil
+	}
+
+	return &boltBucket{b}
+}
+
+func (t *boltTx) Bucket(name []byte) StorageBucket {
+	return wrapBoltBucket(t.tx.Bucket(name))
+}
+
+// bolt 의 에러를 다른 저장소와 같은 에러로 바꾸기 위한 함수
+func boltError(err error) error {
+	switch err {
+	case bolt.ErrTxNotWritable:
+		return errTxNotWritable
+	case bolt.ErrBucketExists:
+		return errBucketExists
+	case bolt.ErrBucketNotFound:
+		return errBucketMissing
+	}
+
+	return err
+}
+
+func (t *boltTx) CreateBucket(name []byte) (StorageBucket, error) {
+	b, err := t.tx.CreateBucket(name)
+
+	return wrapBoltBucket(b), boltError(err)
+}
+
+func (t *boltTx) CreateBucketIfNotExists(name []byte) (StorageBucket, error) {
+	b, err := t.tx.CreateBucketIfNotExists(name)
+
+	return wrapBoltBucket(b), boltError(err)
+}
+
+func (t *boltTx) DeleteBucket(name []byte) error {
+	return boltError(t.tx.DeleteBucket(name))
+}
+
+func (b *boltBucket) Get(key []byte) []byte {
+	return b.b.Get(key)
+}
+
+func (b *boltBucket) Put(key, value []byte) error {
+	return boltError(b.b.Put(key, value))
+}
+
+func (b *boltBucket) Delete(key []byte) error {
+	return boltError(b.b.Delete(key))
+}
+
+func (b *boltBucket) ForEach(fn func(k, v []byte) error) error {
+	return b.b.ForEach(fn)
+}
+
+func (b *boltBucket) ForEachPrefix(prefix []byte, fn func(k, v []byte) error) error {
+	c := b.b.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
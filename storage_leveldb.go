@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"syscall"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// 30. leveldb 저장소
+// leveldb 에는 버킷이 없으므로 키 앞에 버킷 이름을 붙여 구분
+//   - 버킷의 키 : 'b' + 버킷 이름의 길이(1) + 버킷 이름 + 키
+//   - 버킷의 존재 : 'm' + 버킷 이름
+const (
+	leveldbDataPrefix   = 'b'
+	leveldbBucketPrefix = 'm'
+)
+
+// 스냅샷과 트랜잭션이 함께 가진 조회 메서드
+type leveldbReader interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+// 31) leveldb 는 잠긴 디렉터리를 기다리지 않으므로 storageTimeout 동안 다시 시도
+func openLevelDBStorage(path string, readOnly bool) (Storage, error) {
+	deadline := time.Now().Add(storageTimeout)
+	for {
+		db, err := leveldb.OpenFile(path, &opt.Options{ReadOnly: readOnly})
+		if err == nil {
+			return &leveldbStorage{db}, nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s: %w", path, ErrDatabaseLocked)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (s *leveldbStorage) View(fn func(tx StorageTx) error) error {
+	snap, err := s.db.GetSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snap.Release()
+
+	return fn(&leveldbTx{reader: snap})
+}
+
+// leveldb 트랜잭션은 열려 있는 동안 다른 변경을 막으며, Commit 해야 반영됨
+func (s *leveldbStorage) Update(fn func(tx StorageTx) error) error {
+	tr, err := s.db.OpenTransaction()
+	if err == leveldb.ErrReadOnly {
+		return errStorageReadOnly
+	}
+	if err != nil {
+		return err
+	}
+	if err := fn(&leveldbTx{reader: tr, tx: tr, writable: true}); err != nil {
+		tr.Discard()
+		return err
+	}
+
+	return tr.Commit()
+}
+
+func (s *leveldbStorage) Close() error {
+	return s.db.Close()
+}
+
+func leveldbBucketKey(name []byte) []byte {
+	return append([]byte{leveldbBucketPrefix}, name...)
+}
+
+func leveldbDataKey(name []byte) []byte {
+	return append([]byte{leveldbDataPrefix, byte(len(name))}, name...)
+}
+
+func (t *leveldbTx) has(key []byte) bool {
+	_, err := t.reader.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return false
+	}
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return true
+}
+
+func (t *leveldbTx) Bucket(name []byte) StorageBucket {
+	if !t.has(leveldbBucketKey(name)) {
+		return nil
+	}
+
+	return &leveldbBucket{t, leveldbDataKey(name)}
+}
+
+func (t *leveldbTx) CreateBucket(name []byte) (StorageBucket, error) {
+	if !t.writable {
+		return nil, errTxNotWritable
+	}
+	if t.has(leveldbBucketKey(name)) {
+		return nil, errBucketExists
+	}
+	if err := t.tx.Put(leveldbBucketKey(name), []byte{}, nil); err != nil {
+		return nil, err
+	}
+
+	return &leveldbBucket{t, leveldbDataKey(name)}, nil
+}
+
+func (t *leveldbTx) CreateBucketIfNotExists(name []byte) (StorageBucket, error) {
+	if b := t.Bucket(name); b != nil {
+		return b, nil
+	}
+
+	return t.CreateBucket(name)
+}
+
+// 버킷의 모든 키와 버킷을 지움
+func (t *leveldbTx) DeleteBucket(name []byte) error {
+	if !t.writable {
+		return errTxNotWritable
+	}
+	if !t.has(leveldbBucketKey(name)) {
+		return errBucketMissing
+	}
+
+	var keys [][]byte
+	it := t.reader.NewIterator(util.BytesPrefix(leveldbDataKey(name)), nil)
+	for it.Next() {
+		keys = append(keys, append([]byte{}, it.Key()...))
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := t.tx.Delete(key, nil); err != nil {
+			return err
+		}
+	}
+
+	return t.tx.Delete(leveldbBucketKey(name), nil)
+}
+
+func (b *leveldbBucket) key(key []byte) []byte {
+	return append(append([]byte{}, b.prefix...), key...)
+}
+
+func (b *leveldbBucket) Get(key []byte) []byte {
+	value, err := b.tx.reader.Get(b.key(key), nil)
+	if err == leveldb.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return value
+}
+
+func (b *leveldbBucket) Put(key, value []byte) error {
+	if !b.tx.writable {
+		return errTxNotWritable
+	}
+
+	return b.tx.tx.Put(b.key(key), value, nil)
+}
+
+func (b *leveldbBucket) Delete(key []byte) error {
+	if !b.tx.writable {
+		return errTxNotWritable
+	}
+
+	return b.tx.tx.Delete(b.key(key), nil)
+}
+
+func (b *leveldbBucket) ForEach(fn func(k, v []byte) error) error {
+	return b.ForEachPrefix(nil, fn)
+}
+
+func (b *leveldbBucket) ForEachPrefix(prefix []byte, fn func(k, v []byte) error) error {
+	it := b.tx.reader.NewIterator(util.BytesPrefix(b.key(prefix)), nil)
+	defer it.Release()
+
+	for it.Next() {
+		if err := fn(it.Key()[len(b.prefix):], it.Value()); err != nil {
+			return err
+		}
+	}
+
+	return it.Error()
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 30. 메모리 저장소
+// 같은 프로세스에서 같은 경로(-datadir 와 이름)로 다시 열면 같은 저장소를 반환하며, Close 해도 내용은 남음
+// 데이터 디렉터리마다 다른 저장소이므로 한 프로세스에서 여러 노드를 실행할 수 있음(node_test.go)
+var (
+	memoryStoragesMu sync.Mutex
+	memoryStorages   = make(map[string]*memoryStorage)
+)
+
+func openMemoryStorage(path string) Storage {
+	memoryStoragesMu.Lock()
+	defer memoryStoragesMu.Unlock()
+
+	s, ok := memoryStorages[path]
+	if !ok {
+		s = &memoryStorage{buckets: make(map[string]map[string][]byte)}
+		memoryStorages[path] = s
+	}
+
+	return s
+}
+
+func memoryStorageExists(path string) bool {
+	memoryStoragesMu.Lock()
+	defer memoryStoragesMu.Unlock()
+
+	_, ok := memoryStorages[path]
+	return ok
+}
+
+func removeMemoryStorage(path string) {
+	memoryStoragesMu.Lock()
+	defer memoryStoragesMu.Unlock()
+
+	delete(memoryStorages, path)
+}
+
+// 읽기 전용으로 연 메모리 저장소, 같은 이름의 저장소를 공유하며 Update 는 errStorageReadOnly
+type readOnlyStorage struct {
+	Storage
+}
+
+func (s readOnlyStorage) Update(fn func(tx StorageTx) error) error {
+	return errStorageReadOnly
+}
+
+func (s *memoryStorage) View(fn func(tx StorageTx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return fn(&memoryTx{buckets: s.buckets})
+}
+
+// 버킷 목록을 복사한 뒤 변경하는 버킷은 처음 변경할 때 복사하므로, fn 이 에러를 반환하면 원래 내용이 그대로 남음
+func (s *memoryStorage) Update(fn func(tx StorageTx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &memoryTx{buckets: make(map[string]map[string][]byte, len(s.buckets)), copied: make(map[string]bool), writable: true}
+	for name, b := range s.buckets {
+		tx.buckets[name] = b
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	s.buckets = tx.buckets
+
+	return nil
+}
+
+func (s *memoryStorage) Close() error {
+	return nil
+}
+
+func (t *memoryTx) Bucket(name []byte) StorageBucket {
+	if _, ok := t.buckets[string(name)]; !ok {
+		return nil
+	}
+
+	return &memoryBucket{t, string(name)}
+}
+
+func (t *memoryTx) CreateBucket(name []byte) (StorageBucket, error) {
+	if !t.writable {
+		return nil, errTxNotWritable
+	}
+	if _, ok := t.buckets[string(name)]; ok {
+		return nil, errBucketExists
+	}
+	t.buckets[string(name)] = make(map[string][]byte)
+	t.copied[string(name)] = true
+
+	return &memoryBucket{t, string(name)}, nil
+}
+
+func (t *memoryTx) CreateBucketIfNotExists(name []byte) (StorageBucket, error) {
+	if b := t.Bucket(name); b != nil {
+		return b, nil
+	}
+
+	return t.CreateBucket(name)
+}
+
+func (t *memoryTx) DeleteBucket(name []byte) error {
+	if !t.writable {
+		return errTxNotWritable
+	}
+	if _, ok := t.buckets[string(name)]; !ok {
+		return errBucketMissing
+	}
+	delete(t.buckets, string(name))
+
+	return nil
+}
+
+// 트랜잭션에서 처음 변경하는 버킷이라면 복사
+func (b *memoryBucket) writable() (map[string][]byte, error) {
+	if !b.tx.writable {
+		return nil, errTxNotWritable
+	}
+	if !b.tx.copied[b.name] {
+		copied := make(map[string][]byte, len(b.tx.buckets[b.name]))
+		for k, v := range b.tx.buckets[b.name] {
+			copied[k] = v
+		}
+		b.tx.buckets[b.name] = copied
+		b.tx.copied[b.name] = true
+	}
+
+	return b.tx.buckets[b.name], nil
+}
+
+func (b *memoryBucket) Get(key []byte) []byte {
+	return b.tx.buckets[b.name][string(key)]
+}
+
+func (b *memoryBucket) Put(key, value []byte) error {
+	m, err := b.writable()
+	if err != nil {
+		return err
+	}
+	m[string(key)] = append([]byte{}, value...)
+
+	return nil
+}
+
+func (b *memoryBucket) Delete(key []byte) error {
+	m, err := b.writable()
+	if err != nil {
+		return err
+	}
+	delete(m, string(key))
+
+	return nil
+}
+
+func (b *memoryBucket) ForEach(fn func(k, v []byte) error) error {
+	return b.ForEachPrefix(nil, fn)
+}
+
+func (b *memoryBucket) ForEachPrefix(prefix []byte, fn func(k, v []byte) error) error {
+	m := b.tx.buckets[b.name]
+
+	var keys []string
+	for k := range m {
+		if strings.HasPrefix(k, string(prefix)) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := fn([]byte(k), m[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/boltdb/bolt"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// 30. 저장소 인터페이스
+// 블록체인은 bolt 의 *bolt.DB 와 버킷을 직접 사용했기 때문에 다른 저장소를 사용할 수 없었음
+// 버킷(이름이 있는 키-값 집합)과 트랜잭션을 가진 저장소로 추상화하고, -storage 옵션으로 구현을 고름
+//   - bolt    : 기본값, chain.db 파일 하나
+//   - leveldb : chain.leveldb 디렉터리, 버킷은 키 앞에 버킷 이름을 붙여 구분
+//   - memory  : 메모리에만 저장(테스트, 임시 노드용), 프로세스가 끝나면 사라짐
+//
+// 저장소마다 다른 에러는 같은 에러(errTxNotWritable, errBucketExists, errBucketMissing, errStorageReadOnly)로 바꾸어 반환
+// 세 저장소가 같은 계약을 지키는지 storage_test.go 에서 검사
+//
+// Update 의 함수가 에러 없이 끝나면 그 안의 변경이 한 번에 반영되고, 에러를 반환하면 모두 버려짐(원자적 일괄 처리)
+type Storage interface {
+	View(fn func(tx StorageTx) error) error
+	Update(fn func(tx StorageTx) error) error
+	Close() error
+}
+
+// 저장소의 트랜잭션, 없는 버킷은 nil
+type StorageTx interface {
+	Bucket(name []byte) StorageBucket
+	CreateBucket(name []byte) (StorageBucket, error)
+	CreateBucketIfNotExists(name []byte) (StorageBucket, error)
+	DeleteBucket(name []byte) error
+}
+
+// 버킷, Get 이 반환한 값은 트랜잭션 안에서만 유효하므로 트랜잭션 밖에서 사용하려면 복사해야 함
+// ForEach, ForEachPrefix 는 키 순서대로 순회하며 fn 이 에러를 반환하면 멈춤
+type StorageBucket interface {
+	Get(key []byte) []byte
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	ForEach(fn func(k, v []byte) error) error
+	ForEachPrefix(prefix []byte, fn func(k, v []byte) error) error
+}
+
+// bolt 저장소
+type boltStorage struct {
+	db *bolt.DB
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+type boltBucket struct {
+	b *bolt.Bucket
+}
+
+// 메모리 저장소
+// buckets 는 버킷 이름 -> 키 -> 값, Update 는 바꾼 버킷만 복사하여 변경하고 성공하면 교체
+type memoryStorage struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+type memoryTx struct {
+	buckets  map[string]map[string][]byte
+	copied   map[string]bool
+	writable bool
+}
+
+type memoryBucket struct {
+	tx   *memoryTx
+	name string
+}
+
+// leveldb 저장소
+// 조회(View)는 스냅샷, 변경(Update)은 leveldb 트랜잭션으로 처리
+type leveldbStorage struct {
+	db *leveldb.DB
+}
+
+type leveldbTx struct {
+	reader   leveldbReader
+	tx       *leveldb.Transaction
+	writable bool
+}
+
+type leveldbBucket struct {
+	tx     *leveldbTx
+	prefix []byte
+}
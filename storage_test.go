@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// 같은 Storage 계약을 bolt, leveldb, memory 저장소에 대해 검사
+var storageBackends = []string{storageBolt, storageLevelDB, storageMemory}
+
+// backend 의 저장소를 테스트 디렉터리에 열기 위한 함수, 테스트가 끝나면 닫고 지움
+func openTestStorage(t *testing.T, backend string) func(readOnly bool) Storage {
+	t.Helper()
+
+	prevBackend, prevDir := storageBackend, dataDir
+	storageBackend, dataDir = backend, t.TempDir()
+	const name = "test"
+
+	var opened []Storage
+	t.Cleanup(func() {
+		for _, db := range opened {
+			db.Close()
+		}
+		RemoveStorage(name)
+		storageBackend, dataDir = prevBackend, prevDir
+	})
+
+	return func(readOnly bool) Storage {
+		t.Helper()
+		db, err := OpenStorage(name, readOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opened = append(opened, db)
+
+		return db
+	}
+}
+
+func forEachStorage(t *testing.T, test func(t *testing.T, open func(readOnly bool) Storage)) {
+	for _, backend := range storageBackends {
+		t.Run(backend, func(t *testing.T) {
+			test(t, openTestStorage(t, backend))
+		})
+	}
+}
+
+func putTestKeys(t *testing.T, db Storage, bucket string, keys ...string) {
+	t.Helper()
+
+	err := db.Update(func(tx StorageTx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := b.Put([]byte(k), []byte("v"+k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 버킷의 키들을 순서대로 모으기 위한 함수, 버킷이 없다면 nil
+func testKeys(t *testing.T, db Storage, bucket string, prefix []byte) []string {
+	t.Helper()
+
+	var keys []string
+	err := db.View(func(tx StorageTx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		keys = []string{}
+		return b.ForEachPrefix(prefix, func(k, v []byte) error {
+			if !bytes.Equal(v, []byte("v"+string(k))) {
+				t.Errorf("value of %q = %q", k, v)
+			}
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return keys
+}
+
+func equalKeys(a, b []string) bool {
+	if len(a) != len(b) || (a == nil) != (b == nil) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestStorageUpdateRollback(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, open func(bool) Storage) {
+		db := open(false)
+		putTestKeys(t, db, "a", "k1")
+
+		errAbort := errors.New("abort")
+		err := db.Update(func(tx StorageTx) error {
+			b := tx.Bucket([]byte("a"))
+			if err := b.Put([]byte("k2"), []byte("vk2")); err != nil {
+				return err
+			}
+			if err := b.Delete([]byte("k1")); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket([]byte("b")); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if err != errAbort {
+			t.Fatalf("Update() = %v, want %v", err, errAbort)
+		}
+
+		if keys := testKeys(t, db, "a", nil); !equalKeys(keys, []string{"k1"}) {
+			t.Fatalf("keys after rollback = %q, want [k1]", keys)
+		}
+		if keys := testKeys(t, db, "b", nil); keys != nil {
+			t.Fatalf("bucket created in a rolled back update exists: %q", keys)
+		}
+	})
+}
+
+func TestStorageDeleteBucket(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, open func(bool) Storage) {
+		db := open(false)
+		putTestKeys(t, db, "a", "k1", "k2")
+		putTestKeys(t, db, "ab", "k3")
+
+		err := db.Update(func(tx StorageTx) error {
+			if _, err := tx.CreateBucket([]byte("a")); !errors.Is(err, errBucketExists) {
+				t.Errorf("CreateBucket(existing) = %v, want %v", err, errBucketExists)
+			}
+			if err := tx.DeleteBucket([]byte("missing")); !errors.Is(err, errBucketMissing) {
+				t.Errorf("DeleteBucket(missing) = %v, want %v", err, errBucketMissing)
+			}
+			return tx.DeleteBucket([]byte("a"))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if keys := testKeys(t, db, "a", nil); keys != nil {
+			t.Fatalf("deleted bucket still has %q", keys)
+		}
+		if keys := testKeys(t, db, "ab", nil); !equalKeys(keys, []string{"k3"}) {
+			t.Fatalf("other bucket keys = %q, want [k3]", keys)
+		}
+
+		putTestKeys(t, db, "a")
+		if keys := testKeys(t, db, "a", nil); !equalKeys(keys, []string{}) {
+			t.Fatalf("recreated bucket has %q, want none", keys)
+		}
+	})
+}
+
+func TestStorageForEachPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{"all", "", []string{"a", "ab", "ab\x00", "abc", "abd", "ab\xff", "b"}},
+		{"prefix", "ab", []string{"ab", "ab\x00", "abc", "abd", "ab\xff"}},
+		{"binary", "ab\x00", []string{"ab\x00"}},
+		{"none", "c", []string{}},
+		{"after last", "ab\xff\x00", []string{}},
+	}
+
+	forEachStorage(t, func(t *testing.T, open func(bool) Storage) {
+		db := open(false)
+		putTestKeys(t, db, "a", "abd", "b", "ab\xff", "a", "abc", "ab\x00", "ab")
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if keys := testKeys(t, db, "a", []byte(tt.prefix)); !equalKeys(keys, tt.want) {
+					t.Fatalf("ForEachPrefix(%q) = %q, want %q", tt.prefix, keys, tt.want)
+				}
+			})
+		}
+
+		errStop := errors.New("stop")
+		var visited int
+		err := db.View(func(tx StorageTx) error {
+			return tx.Bucket([]byte("a")).ForEach(func(k, v []byte) error {
+				visited++
+				return errStop
+			})
+		})
+		if err != errStop || visited != 1 {
+			t.Fatalf("ForEach() = %v after %d keys, want %v after 1", err, visited, errStop)
+		}
+	})
+}
+
+func TestStorageReadOnly(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, open func(bool) Storage) {
+		db := open(false)
+		putTestKeys(t, db, "a", "k1")
+
+		err := db.View(func(tx StorageTx) error {
+			b := tx.Bucket([]byte("a"))
+			for name, err := range map[string]error{
+				"Put":          b.Put([]byte("k2"), []byte("vk2")),
+				"Delete":       b.Delete([]byte("k1")),
+				"DeleteBucket": tx.DeleteBucket([]byte("a")),
+			} {
+				if !errors.Is(err, errTxNotWritable) {
+					t.Errorf("%s in View = %v, want %v", name, err, errTxNotWritable)
+				}
+			}
+			if _, err := tx.CreateBucket([]byte("b")); !errors.Is(err, errTxNotWritable) {
+				t.Errorf("CreateBucket in View = %v, want %v", err, errTxNotWritable)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+
+		readOnly := open(true)
+		err = readOnly.Update(func(tx StorageTx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte("b"))
+			return err
+		})
+		if !errors.Is(err, errStorageReadOnly) {
+			t.Fatalf("Update on a read-only storage = %v, want %v", err, errStorageReadOnly)
+		}
+		if keys := testKeys(t, readOnly, "a", nil); !equalKeys(keys, []string{"k1"}) {
+			t.Fatalf("read-only keys = %q, want [k1]", keys)
+		}
+	})
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"math/big"
-
-	"github.com/boltdb/bolt"
+	"sync"
 )
 
 // 8) 트랜잭션 기능으로 변경점
 //   - Data 필드 대신 Transactions 필드로 대체
+//
+// 20) 블록 높이 추가로 인한 변경점
+//   - 제네시스 블록(0)부터의 높이를 Height 필드로 가짐
 type Block struct {
 	PrevBlockHash []byte
 	Hash          []byte
@@ -15,24 +17,43 @@ type Block struct {
 	//Data          []byte
 	Transactions []*Transaction
 	Nonce        int64
+	Height       int
 }
 
 // 블록체인은 다수의 블록을 가짐 - 블록체인은 블록의 연결
 // Block을 가지기지만 블록의 직접적 정보가 아닌 db의 정보와 lastHash 값만을 가짐
+// 19) 주소 색인이 켜져 있는지(addrIndex) 여부를 가짐
+// 30) bolt 대신 저장소 인터페이스(Storage)를 가짐
+// 31) 여러 고루틴이 함께 사용할 수 있도록 잠금을 가짐
+//   - writeMu : 블록체인을 변경하는 메서드를 한 번에 하나씩 실행
+//   - mu      : 마지막 블록해시(l)와 addrIndex 를 보호
 type Blockchain struct {
 	//blocks []*Block
-	db *bolt.DB
-	l  []byte
+	db        Storage
+	l         []byte
+	addrIndex bool
+
+	writeMu sync.Mutex
+	mu      sync.RWMutex
 }
 
 // 작업증명(PoW) - 채굴을 위한 작업으로 난이도(Target) 설정
+// 28) 트랜잭션들의 머클 루트(merkleRoot)를 한 번만 계산하여 가지며, 트랜잭션이 없는 블록 헤더로도 만들 수 있음
 type ProofOfWork struct {
-	block  *Block
-	target *big.Int
+	block      *Block
+	target     *big.Int
+	merkleRoot []byte
 }
 
 // 영속성 추가시 블록체인 내부 순회를 위한 구조체
 type blockchainIterator struct {
-	db   *bolt.DB
+	db   Storage
 	hash []byte
 }
+
+// 제네시스 블록부터 마지막 블록 방향으로 순회하기 위한 구조체
+// 31) 생성 시점의 높이 색인(heights)으로 메인 체인의 블록 해시들(hashes)을 모아두므로 순회 중에 재구성되어도 같은 블록들을 반환
+type blockchainForwardIterator struct {
+	db     Storage
+	hashes [][]byte
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// 12. 블록 보상 반감기 및 총 발행량 제한
+// 기존에는 코인베이스 트랜잭션이 언제나 subsidy(10) 만큼의 보상을 지급하였음
+// 비트코인과 같이 일정 블록 높이(subsidyHalvingInterval)마다 보상을 절반으로 줄이고, 총 발행량은 MaxMoney() 를 넘지 않도록 제한
+//   - 보상 : subsidy >> (height / subsidyHalvingInterval)
+//   - 총 발행량 : subsidy * subsidyHalvingInterval * 2 를 넘지 않음
+//
+// 33) 최초 블록 보상과 반감기는 네트워크(netParams)에 따름
+
+// 최대 발행량
+func MaxMoney() uint64 {
+	return netParams.Subsidy * uint64(netParams.SubsidyHalvingInterval) * 2
+}
+
+// 블록 높이에 해당하는 블록 보상을 구하기 위한 함수
+// 반감기를 지날 때마다 보상을 절반으로 줄이며, 64번 이상 반감되면 보상은 0
+func GetBlockSubsidy(height int) uint64 {
+	halvings := height / netParams.SubsidyHalvingInterval
+	if halvings >= 64 {
+		return 0
+	}
+
+	return netParams.Subsidy >> uint(halvings)
+}
+
+// 제네시스 블록부터 height 블록까지 발행 일정에 따라 발행될 수 있는 코인의 총 합
+func ScheduledSupply(height int) uint64 {
+	var total uint64
+
+	for start := 0; start <= height; start += netParams.SubsidyHalvingInterval {
+		blocks := netParams.SubsidyHalvingInterval
+		if start+blocks > height+1 {
+			blocks = height + 1 - start
+		}
+		reward := GetBlockSubsidy(start)
+		if reward == 0 {
+			break
+		}
+		total += reward * uint64(blocks)
+	}
+
+	return total
+}
+
+// 트랜잭션의 수수료(입력의 합 - 출력의 합)를 구하기 위한 메서드
+// 출력의 합이 입력의 합보다 크다면 없는 코인을 만들어내는 것이므로 오류를 반환
+// 26) 이전 트랜잭션을 블록체인에서 찾지 않고 입력이 참조하는 출력을 UTXO 집합에서 찾음, 이미 소비된 출력을 참조하면 오류
+func (bc *Blockchain) TransactionFee(tx *Transaction) (uint64, error) {
+	if tx.IsCoinbase() {
+		return 0, nil
+	}
+
+	return transactionFee(tx, bc.findInputOutputs(tx.Vin))
+}
+
+// 입력이 참조하는 출력들(키는 txid:idx)로 수수료를 구하기 위한 함수
+func transactionFee(tx *Transaction, prevOuts map[string]TXOutput) (uint64, error) {
+	var in, out uint64
+	for _, vin := range tx.Vin {
+		prevOut, ok := prevOuts[Outpoint{vin.Txid, vin.Vout}.String()]
+		if !ok {
+			return 0, fmt.Errorf("referenced output %s:%d not found", hex.EncodeToString(vin.Txid), vin.Vout)
+		}
+		in += prevOut.Value
+	}
+	for _, vout := range tx.Vout {
+		if vout.Value > MaxMoney() {
+			return 0, fmt.Errorf("output value %d exceeds max money %d", vout.Value, MaxMoney())
+		}
+		out += vout.Value
+	}
+
+	if out > in {
+		return 0, fmt.Errorf("transaction %x spends %d but only has %d", tx.ID, out, in)
+	}
+
+	return in - out, nil
+}
+
+// 블록에 포함될 트랜잭션들의 코인베이스를 검증하기 위한 메서드
+//   - 코인베이스 트랜잭션은 블록당 하나이며 첫 번째 트랜잭션이어야 함
+//   - 코인베이스의 출력의 합은 블록 보상과 수수료의 합을 넘을 수 없음
+func (bc *Blockchain) ValidateCoinbase(transactions []*Transaction, height int) error {
+	var fees, reward uint64
+
+	for i, tx := range transactions {
+		if !tx.IsCoinbase() {
+			fee, err := bc.TransactionFee(tx)
+			if err != nil {
+				return err
+			}
+			fees += fee
+			continue
+		}
+
+		if i != 0 {
+			return fmt.Errorf("coinbase transaction %x is not the first transaction", tx.ID)
+		}
+		for _, out := range tx.Vout {
+			reward += out.Value
+		}
+	}
+
+	if allowed := GetBlockSubsidy(height) + fees; reward > allowed {
+		return fmt.Errorf("coinbase pays %d, but block subsidy and fees at height %d are %d", reward, height, allowed)
+	}
+
+	return nil
+}
+
+// 모든 UTXO의 합, 즉 지금까지 발행되어 아직 소비되지 않은 코인의 총량을 구하기 위한 메서드
+func (bc *Blockchain) GetSupply() uint64 {
+	var supply uint64
+
+	for _, outs := range bc.FindAllUTXO() {
+		for _, out := range outs {
+			supply += out.Value
+		}
+	}
+
+	return supply
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -10,10 +9,6 @@ import (
 	"github.com/btcsuite/btcd/btcutil/base58"
 )
 
-const (
-	subsidy = 10 // BTC
-)
-
 // 새로운 트랜잭션 생성을 위한 함수
 // 트랜잭션의 ID(해시값)의 경우 별도로 생성
 func NewTransaction(vin []TXInput, vout []TXOutput) *Transaction {
@@ -36,8 +31,33 @@ func (tx *Transaction) SetID() {
 	tx.ID = hash[:]
 }
 
+// 트랜잭션을 직렬화하기 위한 메서드
+func (tx *Transaction) Serialize() []byte {
+	result, err := json.Marshal(tx)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return result
+}
+
+// 서명을 제외한 트랜잭션의 해시를 구하기 위한 메서드
+// 트랜잭션의 ID 는 서명 전에 만들어지므로, 서명된 트랜잭션도 Hash() 와 ID 가 같아야 함
+// 오프라인 서명시 함께 받은 이전 트랜잭션이 변조되지 않았는지 확인하기 위해 사용
+func (tx *Transaction) Hash() []byte {
+	txCopy := Transaction{nil, make([]TXInput, len(tx.Vin)), tx.Vout}
+	for i, in := range tx.Vin {
+		txCopy.Vin[i] = TXInput{in.Txid, in.Vout, nil, in.PubKey}
+	}
+	txCopy.SetID()
+
+	return txCopy.ID
+}
+
 // 트랜잭션의 ID 를 묶어서 해싱하기 위한 메서드
 // 작업증명을 위해 사용되며, 작업증명을 위한 데이터를 준비할때 Block.Data를 사용하였지만 트랜잭션 기능이 추가되며 Block.Transactions로 변경
+// 28. 트랜잭션 ID 들을 이어 붙여 해싱하지 않고 머클 트리의 루트를 사용
+// 블록 전체가 없어도 머클 증명(MerkleProof)으로 트랜잭션이 블록에 포함되었는지 검증할 수 있음
 func (b *Block) HashTransaction() []byte {
 	var txHashes [][]byte
 
@@ -45,9 +65,7 @@ func (b *Block) HashTransaction() []byte {
 		txHashes = append(txHashes, tx.ID)
 	}
 
-	txHash := sha256.Sum256(bytes.Join(txHashes, []byte{}))
-
-	return txHash[:]
+	return MerkleRoot(txHashes)
 }
 
 // 블록을 채굴하면 채굴자에게 보상을 주기위한 제일 첫 번째 트랜잭션을 위한 함수
@@ -56,9 +74,20 @@ func (b *Block) HashTransaction() []byte {
 //   - 코인베이스 트랜잭션을 생성할 때 주소를 받아옴
 //   - TXOutput 을 생성할 때 Base58CheckDecode 로 처리하고 공개키 해시를 출력에 넣어함
 //   - txout = TXOutput -> NewTXOutput()으로 변경
-func NewCoinbaseTX(data, to string) *Transaction {
+//
+// 12. 보상 반감기로 인한 변경점
+//   - 블록 높이(height)를 받아 GetBlockSubsidy() 만큼의 보상을 지급
+//   - 같은 주소로 보상을 받는 코인베이스 트랜잭션의 ID가 겹치지 않도록 기본 data 에 높이를 포함
+//
+// 22. 채굴 기능으로 인한 변경점
+//   - 블록에 포함된 트랜잭션들의 수수료(fees)를 보상에 더해 지급
+func NewCoinbaseTX(data, to string, height int, fees uint64) *Transaction {
+	if data == "" {
+		data = fmt.Sprintf("Reward to '%s' at height %d", to, height)
+	}
+
 	txin := TXInput{[]byte{}, -1, nil, []byte(data)}
-	txout := NewTXOutput(subsidy, to)
+	txout := NewTXOutput(GetBlockSubsidy(height)+fees, to)
 
 	return NewTransaction([]TXInput{txin}, []TXOutput{*txout})
 }
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// 27. 블록 되돌리기 기록(undo)
+// TXInput 은 소비한 출력의 위치(Txid, Vout)만 가지므로 블록을 해제하려면 이전 트랜잭션을 다시 찾아야 했음
+// 블록을 연결(connectBlock)할 때 UTXO 집합에서 지운 출력들을 undo 버킷에 블록 해시를 키로 저장하고,
+// 블록을 해제(disconnectBlock)할 때 이 기록으로 UTXO 집합을 되돌림
+//   - invalidateblock : 블록을 invalid 로 표시하고 메인 체인에 있다면 그 이전 블록까지 되돌림
+//   - rollback        : 지정한 높이까지 마지막 블록들을 해제
+const UndoBucket = "undo"
+
+// 블록 되돌리기 기록을 저장하기 위한 함수
+// 블록을 연결하는 같은 bolt 트랜잭션 안에서 호출
+func putBlockUndo(tx StorageTx, hash []byte, undo *BlockUndo) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(UndoBucket))
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(undo)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(hash, encoded)
+}
+
+// 블록 되돌리기 기록을 가져오기 위한 함수
+// 기록이 없거나 블록의 트랜잭션, 입력 수와 맞지 않으면 에러
+func getBlockUndo(tx StorageTx, block *Block) (*BlockUndo, error) {
+	var encoded []byte
+	if b := tx.Bucket([]byte(UndoBucket)); b != nil {
+		encoded = b.Get(block.Hash)
+	}
+	if encoded == nil {
+		return nil, fmt.Errorf("no undo data for block %x", block.Hash)
+	}
+
+	var undo BlockUndo
+	if err := json.Unmarshal(encoded, &undo); err != nil {
+		return nil, err
+	}
+
+	if len(undo.Spent) != len(block.Transactions) {
+		return nil, fmt.Errorf("undo data for block %x does not match its transactions", block.Hash)
+	}
+	for i, t := range block.Transactions {
+		if !t.IsCoinbase() && len(undo.Spent[i]) != len(t.Vin) {
+			return nil, fmt.Errorf("undo data for block %x does not match its transactions", block.Hash)
+		}
+	}
+
+	return &undo, nil
+}
+
+// 마지막 블록부터 height 높이의 블록이 마지막 블록이 될 때까지 블록들을 해제하기 위한 메서드
+// 해제된 블록들을 마지막 블록부터 순서대로 반환하며, 블록과 블록 색인은 다른 갈래의 블록으로 남음
+func (bc *Blockchain) RollbackTo(height int) ([]*Block, error) {
+	bc.writeMu.Lock()
+	defer bc.writeMu.Unlock()
+
+	return bc.rollbackTo(height)
+}
+
+// 쓰기 잠금을 잡은 메서드(reorganize, InvalidateBlock)에서 호출
+func (bc *Blockchain) rollbackTo(height int) ([]*Block, error) {
+	best := bc.GetBestHeight()
+	if height < 0 || height > best {
+		return nil, fmt.Errorf("height %d is out of range 0-%d", height, best)
+	}
+
+	var disconnected []*Block
+	for bc.GetBestHeight() > height {
+		tip, err := bc.GetBlock(bc.l)
+		if err != nil {
+			return disconnected, err
+		}
+		if err := bc.disconnectBlock(tip); err != nil {
+			return disconnected, err
+		}
+		disconnected = append(disconnected, tip)
+	}
+
+	return disconnected, nil
+}
+
+// 블록을 invalid 로 표시하기 위한 메서드
+// 블록이 메인 체인에 있다면 그 이전 블록까지 되돌리고 해제된 블록들을 모두 invalid 로 표시
+// invalid 인 블록과 그 이후의 블록들은 다시 받거나 재구성하여도 메인 체인에 연결되지 않음
+// 27) 되돌린 뒤 누적 작업량이 메인 체인보다 많은 다른 갈래가 있다면 그 갈래로 재구성(activateBestChain)
+func (bc *Blockchain) InvalidateBlock(hash []byte) ([]*Block, error) {
+	bc.writeMu.Lock()
+	defer bc.writeMu.Unlock()
+
+	block, err := bc.GetBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	if block.Height == 0 {
+		return nil, errors.New("cannot invalidate the genesis block")
+	}
+
+	invalid := []*Block{block}
+	var disconnected []*Block
+	if bc.IsMainChain(block.Hash, block.Height) {
+		disconnected, err = bc.rollbackTo(block.Height - 1)
+		if err != nil {
+			return nil, err
+		}
+		invalid = disconnected
+	}
+
+	for _, b := range invalid {
+		if err := bc.setBlockStatus(b, blockStatusInvalid); err != nil {
+			return nil, err
+		}
+	}
+
+	reorganized, err := bc.activateBestChain()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(disconnected, reorganized...), nil
+}
+
+// 메인 체인보다 누적 작업량이 많은 갈래로 재구성하기 위한 메서드
+// 작업량이 가장 많은 갈래부터 시도하며, 재구성에 실패하면 실패한 블록들이 invalid 로 표시되므로 갈래의 끝을 다시 구하여 시도
+// invalid 인 블록 이후의 갈래는 시도하지 않음
+// 메인 체인에서 해제된 블록들을 마지막 블록부터 순서대로 반환
+func (bc *Blockchain) activateBestChain() ([]*Block, error) {
+	tried := make(map[string]bool)
+	for {
+		indexes := bc.readBlockIndexes()
+		var best *BlockIndex
+		for _, tip := range chainTipIndexes(indexes) {
+			if tried[string(tip.Hash)] || hasInvalidAncestor(indexes, tip) {
+				continue
+			}
+			if best == nil || tip.Work().Cmp(best.Work()) > 0 {
+				best = tip
+			}
+		}
+		if best == nil || best.Work().Cmp(indexes[string(bc.l)].Work()) <= 0 {
+			return nil, nil
+		}
+		tried[string(best.Hash)] = true
+
+		block, err := bc.GetBlock(best.Hash)
+		if err != nil {
+			return nil, err
+		}
+		disconnected, err := bc.reorganize(block)
+		if err == nil {
+			return disconnected, nil
+		}
+		log.Printf("Skipping branch %x: %v", best.Hash, err)
+	}
+}
+
+// 블록이나 그 이전 블록 중 invalid 인 블록이 있는지 확인하기 위한 함수
+func hasInvalidAncestor(indexes map[string]*BlockIndex, index *BlockIndex) bool {
+	for i := index; i != nil; i = indexes[string(i.PrevBlockHash)] {
+		if i.Status == blockStatusInvalid {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,8 @@
+package main
+
+// 27. 블록 되돌리기 기록(undo)
+// 블록의 트랜잭션 순서대로, 각 입력의 순서대로 그 입력이 소비한 출력(금액과 PubKeyHash)을 가짐
+// 코인베이스는 소비한 출력이 없으므로 비어 있음
+type BlockUndo struct {
+	Spent [][]TXOutput
+}
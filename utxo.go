@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"log"
+	"sort"
 
 	"github.com/btcsuite/btcd/btcutil/base58"
 )
@@ -27,7 +28,13 @@ import (
 //			- 기존에는 TXInput.ScriptSig 값으로 출력의 사용여부를 찾았으나 지금은 구현에 변화를 주었기 때문에 공개키 해시(PubKeyHash)로 비교
 //		-  UTXO 를 찾을 때 조건문 변경
 //			- 기존에는 TXOuput.ScriptPubKey를 비교하였지만, 이제는 TXOutput.PubKeyHash값으로 출력값을 잠그기때문에 이 부분을 변경
+//
+// 19. 주소 색인이 켜져 있다면 블록을 순회하지 않고 색인의 UTXO 가 속한 트랜잭션들을 반환
 func (bc *Blockchain) FindUnspentTransactions(pubKeyHash []byte) []*Transaction {
+	if bc.hasAddrIndex() {
+		return bc.findIndexedUnspentTransactions(pubKeyHash)
+	}
+
 	bci := NewBlockchainIterator(bc)
 
 	spentTXOs := make(map[string][]int)
@@ -90,8 +97,21 @@ func (tx *Transaction) IsCoinbase() bool {
 // 특정 주소가 가진 자금을 확인하기 위한 메서드
 // 10. 주소를 이용한 거래기능으로 인한 변경점
 //		- 기존 address에서 공개키 해시를 받는걸로 변경
+// 19. 주소 색인이 켜져 있다면 색인에서 찾음
 func (bc *Blockchain) FindUTXO(pubKeyHash []byte) []TXOutput {
 	var UTXOs []TXOutput
+
+	if bc.hasAddrIndex() {
+		indexed, _, err := bc.findIndexedUTXO(pubKeyHash)
+		if err != nil {
+			log.Panic(err)
+		}
+		for _, u := range indexed {
+			UTXOs = append(UTXOs, u.Output)
+		}
+		return UTXOs
+	}
+
 	unspentTXs := bc.FindUnspentTransactions(pubKeyHash)
 
 	for _, tx := range unspentTXs {
@@ -125,3 +145,116 @@ func (bc *Blockchain) GetBalance(address string) uint64 {
 
 	return balance
 }
+
+// 12. 총 발행량 확인을 위해 추가한 메서드
+// 특정 주소가 아닌 블록체인 전체의 UTXO를 찾기 위한 메서드
+// 마지막 블록부터 순회하기 때문에 출력을 만나기 전에 그 출력을 소비한 입력을 먼저 만나게 됨
+// 반환값은 트랜잭션 ID(hex) -> 출력 인덱스 -> 출력
+// 26. UTXO 집합(chainstate)이 있다면 블록을 순회하지 않고 UTXO 집합을 읽음
+func (bc *Blockchain) FindAllUTXO() map[string]map[int]TXOutput {
+	if UTXO, ok := bc.readUTXOSet(); ok {
+		return UTXO
+	}
+
+	UTXO := make(map[string]map[int]TXOutput)
+	spentTXOs := make(map[string]map[int]bool)
+
+	bci := NewBlockchainIterator(bc)
+	for bci.HasNext() {
+		for _, tx := range bci.Next().Transactions {
+			txID := hex.EncodeToString(tx.ID)
+
+			for outIdx, out := range tx.Vout {
+				if spentTXOs[txID][outIdx] {
+					continue
+				}
+				if UTXO[txID] == nil {
+					UTXO[txID] = make(map[int]TXOutput)
+				}
+				UTXO[txID][outIdx] = out
+			}
+
+			if !tx.IsCoinbase() {
+				for _, in := range tx.Vin {
+					hash := hex.EncodeToString(in.Txid)
+					if spentTXOs[hash] == nil {
+						spentTXOs[hash] = make(map[int]bool)
+					}
+					spentTXOs[hash][in.Vout] = true
+				}
+			}
+		}
+	}
+
+	return UTXO
+}
+
+// 13. 코인 선택 기능을 위해 추가한 메서드
+// 공개키 해시로 잠긴 UTXO 를 출력 단위로 찾기 위한 메서드
+// FindUnspentTransactions() 는 트랜잭션 단위로 반환하기 때문에 이미 소비된 출력과 구분할 수 없어 FindAllUTXO() 를 사용
+// 결과가 항상 같은 순서가 되도록 트랜잭션 ID 와 출력 인덱스로 정렬
+// 19. 주소 색인이 켜져 있다면 색인에서 찾음(색인의 키 순서가 트랜잭션 ID, 출력 인덱스 순서)
+func (bc *Blockchain) FindSpendableOutputs(pubKeyHash []byte) []UTXO {
+	var UTXOs []UTXO
+
+	if bc.hasAddrIndex() {
+		UTXOs, _, err := bc.findIndexedUTXO(pubKeyHash)
+		if err != nil {
+			log.Panic(err)
+		}
+		return UTXOs
+	}
+
+	for txID, outs := range bc.FindAllUTXO() {
+		txid, err := hex.DecodeString(txID)
+		if err != nil {
+			log.Panic(err)
+		}
+		for outIdx, out := range outs {
+			if bytes.Compare(out.PubKeyHash, pubKeyHash) == 0 {
+				UTXOs = append(UTXOs, UTXO{Outpoint{txid, outIdx}, out})
+			}
+		}
+	}
+
+	sort.Slice(UTXOs, func(i, j int) bool {
+		if c := bytes.Compare(UTXOs[i].Txid, UTXOs[j].Txid); c != 0 {
+			return c < 0
+		}
+		return UTXOs[i].Vout < UTXOs[j].Vout
+	})
+
+	return UTXOs
+}
+
+// 주소 색인에서 찾은 UTXO 들이 속한 트랜잭션들을 블록에서 읽어오기 위한 메서드
+func (bc *Blockchain) findIndexedUnspentTransactions(pubKeyHash []byte) []*Transaction {
+	var unspentTXs []*Transaction
+
+	UTXOs, outputs, err := bc.findIndexedUTXO(pubKeyHash)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	seen := make(map[string]bool)
+	for i, u := range UTXOs {
+		txID := hex.EncodeToString(u.Txid)
+		if seen[txID] {
+			continue
+		}
+		seen[txID] = true
+
+		block, err := bc.GetBlock(outputs[i].BlockHash)
+		if err != nil {
+			log.Panic(err)
+		}
+		for _, tx := range block.Transactions {
+			if bytes.Compare(tx.ID, u.Txid) == 0 {
+				unspentTXs = append(unspentTXs, tx)
+				break
+			}
+		}
+	}
+
+	return unspentTXs
+}
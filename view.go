@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+// 21. printchain 을 위한 블록, 트랜잭션 출력 형식
+
+// 블록을 BlockView 로 변환하기 위한 함수, 작업증명의 유효성(PoW)도 함께 검사
+func NewBlockView(block *Block) BlockView {
+	view := BlockView{
+		Height:        block.Height,
+		Hash:          hex.EncodeToString(block.Hash),
+		PrevBlockHash: hex.EncodeToString(block.PrevBlockHash),
+		Timestamp:     block.Timestamp,
+		Nonce:         block.Nonce,
+		PoW:           NewProofOfWork(block).Validate(block),
+	}
+	for _, tx := range block.Transactions {
+		view.Transactions = append(view.Transactions, NewTxView(tx))
+	}
+
+	return view
+}
+
+// 트랜잭션을 TxView 로 변환하기 위한 함수
+func NewTxView(tx *Transaction) TxView {
+	view := TxView{Txid: hex.EncodeToString(tx.ID), Coinbase: tx.IsCoinbase()}
+
+	for _, in := range tx.Vin {
+		if view.Coinbase {
+			view.Inputs = append(view.Inputs, InputView{Vout: in.Vout, Data: string(in.PubKey)})
+			continue
+		}
+		view.Inputs = append(view.Inputs, InputView{
+			Txid:    hex.EncodeToString(in.Txid),
+			Vout:    in.Vout,
+			Address: base58.CheckEncode(HashPubKey(in.PubKey), netParams.AddressVersion),
+		})
+	}
+	for _, out := range tx.Vout {
+		view.Outputs = append(view.Outputs, OutputView{out.Value, base58.CheckEncode(out.PubKeyHash, netParams.AddressVersion)})
+	}
+
+	return view
+}
+
+// 블록을 텍스트로 출력하기 위한 메서드
+func (v BlockView) Print(w io.Writer) {
+	fmt.Fprintf(w, "Height: %d\n", v.Height)
+	fmt.Fprintf(w, "Hash: %s\n", v.Hash)
+	fmt.Fprintf(w, "PrevBlockHash: %s\n", v.PrevBlockHash)
+	fmt.Fprintf(w, "Timestamp: %s\n", formatTimestamp(v.Timestamp))
+	fmt.Fprintf(w, "Nonce: %d\n", v.Nonce)
+	fmt.Fprintf(w, "PoW: %t\n", v.PoW)
+	fmt.Fprintf(w, "Transactions: %d\n", len(v.Transactions))
+	for _, tx := range v.Transactions {
+		tx.Print(w)
+	}
+}
+
+// 트랜잭션을 텍스트로 출력하기 위한 메서드
+func (v TxView) Print(w io.Writer) {
+	if v.Coinbase {
+		fmt.Fprintf(w, "  Transaction %s (coinbase)\n", v.Txid)
+	} else {
+		fmt.Fprintf(w, "  Transaction %s\n", v.Txid)
+	}
+	for i, in := range v.Inputs {
+		if v.Coinbase {
+			fmt.Fprintf(w, "    Input %d: coinbase '%s'\n", i, in.Data)
+		} else {
+			fmt.Fprintf(w, "    Input %d: %s:%d from %s\n", i, in.Txid, in.Vout, in.Address)
+		}
+	}
+	for i, out := range v.Outputs {
+		fmt.Fprintf(w, "    Output %d: %d to %s\n", i, out.Value, out.Address)
+	}
+}
+
+// UTXO 를 UTXOView 로 변환하기 위한 함수
+func NewUTXOView(u UTXO) UTXOView {
+	return UTXOView{hex.EncodeToString(u.Txid), u.Vout, u.Output.Value, base58.CheckEncode(u.Output.PubKeyHash, netParams.AddressVersion)}
+}
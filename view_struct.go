@@ -0,0 +1,41 @@
+package main
+
+// 21. 블록과 트랜잭션을 사람이 읽을 수 있는 형태로 보여주기 위한 구조체
+// 해시는 Block.Serialize() 의 base64 대신 hex 문자열로, 공개키 해시는 주소로 표현
+type BlockView struct {
+	Height        int      `json:"height"`
+	Hash          string   `json:"hash"`
+	PrevBlockHash string   `json:"prevBlockHash"`
+	Timestamp     int64    `json:"timestamp"`
+	Nonce         int64    `json:"nonce"`
+	PoW           bool     `json:"pow"`
+	Transactions  []TxView `json:"transactions"`
+}
+
+type TxView struct {
+	Txid     string       `json:"txid"`
+	Coinbase bool         `json:"coinbase"`
+	Inputs   []InputView  `json:"inputs"`
+	Outputs  []OutputView `json:"outputs"`
+}
+
+// 입력이 참조하는 출력(Txid:Vout)과 서명한 주소, 코인베이스 트랜잭션의 입력은 주소 대신 Data 를 가짐
+type InputView struct {
+	Txid    string `json:"txid,omitempty"`
+	Vout    int    `json:"vout"`
+	Address string `json:"address,omitempty"`
+	Data    string `json:"data,omitempty"`
+}
+
+type OutputView struct {
+	Value   uint64 `json:"value"`
+	Address string `json:"address"`
+}
+
+// 소비되지 않은 출력(UTXO)
+type UTXOView struct {
+	Txid    string `json:"txid"`
+	Vout    int    `json:"vout"`
+	Value   uint64 `json:"value"`
+	Address string `json:"address"`
+}
@@ -47,13 +47,25 @@ func NewWallet() *Wallet {
 // 지갑의 주소 생성을 위한 메서드
 // 주소는 개인키로부터 도출되며, 비트코인 주소의 경우 주소의 접두사로 1 이 붙음
 // 공개키를 더블 해싱(Double-Hashing)하여 SHA256, RIPEMD160 를 각각 한 번씩 해주고, 비트코인 주소를 의미하는 버전 접두어 0x00 을 붙인 다음, 마지막으로 Base58CheckEncode를 하여 주소 생성
+// 33) 버전 접두어는 네트워크(netParams.AddressVersion)에 따름
 func (w *Wallet) GetAddress() string {
 	publicRIPEMD160 := HashPubKey(w.PubKey)
-	version := byte(0x00)
+	version := netParams.AddressVersion
 
 	return base58.CheckEncode(publicRIPEMD160, version)
 }
 
+// 주소가 올바른지 검사하기 위한 함수
+// Base58CheckDecode 의 체크섬, 버전 접두어(netParams.AddressVersion), 공개키 해시의 길이(RIPEMD160, 20바이트)를 확인
+func ValidateAddress(address string) bool {
+	pubKeyHash, version, err := base58.CheckDecode(address)
+	if err != nil {
+		return false
+	}
+
+	return version == netParams.AddressVersion && len(pubKeyHash) == ripemd160.Size
+}
+
 // 공개키를 더블 해싱 하기 위한 함수
 // SHA256과 RIPEMD160로 해성 처리 후 반환
 func HashPubKey(pubKey []byte) []byte {
@@ -71,7 +83,7 @@ func HashPubKey(pubKey []byte) []byte {
 // wallet.dat 파일을 만들기 위한 함수 -> wallet.dat에서 wallet.json으로 변경
 // 함수의 이름이 소문자로 시작하기때문에 외부에서 접근하지 않는 것을 전재로 함
 func createKeyStore() error {
-	file, err := os.OpenFile(walletFile, os.O_CREATE, 0644)
+	file, err := os.OpenFile(dataPath(walletFile), os.O_CREATE, 0644)
 	if err != nil {
 		return err
 	}
@@ -84,17 +96,24 @@ func createKeyStore() error {
 func NewKeyStore() *KeyStore {
 	keyStore := KeyStore{make(map[string]*Wallet)}
 
-	if _, err := os.Stat(walletFile); os.IsNotExist(err) {
+	if _, err := os.Stat(dataPath(walletFile)); os.IsNotExist(err) {
 		err := createKeyStore()
 		if err != nil {
 			log.Panic(err)
 		}
 	} else {
-		fileContent, err := ioutil.ReadFile(walletFile)
+		fileContent, err := ioutil.ReadFile(dataPath(walletFile))
 		if err != nil {
 			log.Panic(err)
 		}
 		json.Unmarshal(fileContent, &keyStore)
+
+		// elliptic.Curve 는 인터페이스라 json 으로 복원되지 않으므로 곡선을 다시 지정
+		for _, wallet := range keyStore.Wallets {
+			if !wallet.IsWatchOnly() {
+				wallet.PrivKey.Curve = elliptic.P256()
+			}
+		}
 	}
 	return &keyStore
 }
@@ -112,7 +131,7 @@ func (ks *KeyStore) Save() {
 	var out bytes.Buffer
 	json.Indent(&out, result, "", "	")
 
-	err = ioutil.WriteFile(walletFile, []byte(out.String()), 0644)
+	err = ioutil.WriteFile(dataPath(walletFile), []byte(out.String()), 0644)
 	if err != nil {
 		log.Panic(err)
 	}
@@ -146,6 +165,62 @@ func (ks *KeyStore) CreateWallet() *Wallet {
 	return wallet
 }
 
+// 공개키 해시에 해당하는 지갑의 개인키를 찾기 위한 메서드(KeyLookup)
+// 공개키 해시로 주소를 만들어 .Wallets 에서 찾음
+// 17) 감시 전용 주소는 개인키가 없으므로 오류, 다른 키로 서명한 입력은 ValidateTransaction() 에서 거부되므로 감시 전용 주소의 출력은 사용할 수 없음
+func (ks *KeyStore) FindKey(pubKeyHash []byte) (*ecdsa.PrivateKey, error) {
+	address := base58.CheckEncode(pubKeyHash, netParams.AddressVersion)
+
+	wallet := ks.Wallets[address]
+	if wallet == nil {
+		return nil, fmt.Errorf("no private key for address '%s'", address)
+	}
+	if wallet.IsWatchOnly() {
+		return nil, fmt.Errorf("address '%s' is watch-only and cannot sign", address)
+	}
+
+	return wallet.PrivKey, nil
+}
+
+// 17. 감시 전용 지갑 추가
+// 개인키 없이 주소나 공개키만 가지고 있는 지갑인지 확인하기 위한 메서드
+func (w *Wallet) IsWatchOnly() bool {
+	return w.PrivKey == nil
+}
+
+// 주소나 공개키를 감시 전용 지갑으로 키스토어에 등록하기 위한 메서드
+// 공개키가 주어지면 공개키로 주소를 만들며, 주소도 함께 주어진 경우 두 주소가 같아야 함
+// 이미 개인키를 가지고 있는 주소는 감시 전용으로 바꾸지 않음
+func (ks *KeyStore) AddWatchOnly(address string, pubKey []byte) (string, error) {
+	if len(pubKey) > 0 {
+		derived := (&Wallet{nil, pubKey}).GetAddress()
+		if address != "" && address != derived {
+			return "", fmt.Errorf("public key belongs to '%s', not '%s'", derived, address)
+		}
+		address = derived
+	}
+	if !ValidateAddress(address) {
+		return "", fmt.Errorf("invalid address '%s'", address)
+	}
+
+	if wallet := ks.Wallets[address]; wallet != nil {
+		if !wallet.IsWatchOnly() {
+			return "", fmt.Errorf("address '%s' already has a private key", address)
+		}
+		if len(pubKey) == 0 {
+			pubKey = wallet.PubKey
+		}
+	}
+	if len(pubKey) == 0 {
+		pubKey = nil
+	}
+
+	ks.Wallets[address] = &Wallet{nil, pubKey}
+	ks.Save()
+
+	return address, nil
+}
+
 // 공개키 해시가 입력에 사용된 .PubKey 와 동일한지 검사를 위한 메서드(추후 이동 필요)
 // UTXO(Unspent Transaction Output)와 관련된 메서드 및 함수에서 사용
 func (in *TXInput) UsesKey(pubKeyHash []byte) bool {
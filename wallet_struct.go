@@ -4,6 +4,9 @@ import "crypto/ecdsa"
 
 const walletFile = "wallet.json"
 
+// 17. 감시 전용(watch-only) 지갑 추가로 인한 변경점
+//   - 개인키가 없는(PrivKey == nil) 지갑은 감시 전용이며, 주소만 등록한 경우 공개키(PubKey)도 없음
+//   - 같은 wallet.json 형식으로 저장되며 잔액 조회나 서명되지 않은 거래 생성에는 사용할 수 있지만 서명은 할 수 없음
 type Wallet struct {
 	PrivKey *ecdsa.PrivateKey
 	PubKey  []byte
@@ -14,3 +17,7 @@ type Wallet struct {
 type KeyStore struct {
 	Wallets map[string]*Wallet
 }
+
+// 공개키 해시에 해당하는 개인키를 찾기 위한 함수
+// 트랜잭션의 입력마다 서로 다른 지갑의 개인키로 서명하기 위해 사용
+type KeyLookup func(pubKeyHash []byte) (*ecdsa.PrivateKey, error)
@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+// 감시 전용 주소의 출력은 키스토어로 서명할 수 없고, 키스토어의 다른 키로 서명해도 사용할 수 없음
+func TestSpendWatchOnlyOutput(t *testing.T) {
+	bc := newTestBlockchain(t)
+	owner := NewWallet()
+	if _, err := bc.Generate(1, owner.GetAddress()); err != nil {
+		t.Fatal(err)
+	}
+
+	keyStore := NewKeyStore()
+	address, err := keyStore.AddWatchOnly("", owner.PubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := keyStore.CreateWallet()
+
+	tx, err := bc.CreateTransaction([]string{address}, map[string][]byte{address: owner.PubKey}, []Recipient{{other.GetAddress(), 1}}, "", LargestFirst{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.SignTransaction(keyStore.FindKey, tx); err == nil {
+		t.Fatal("signed for a watch-only address")
+	}
+
+	// 키스토어에 있는 다른 지갑의 공개키와 개인키로 서명
+	stolen := newTestTransaction(t, bc, owner, other, other.GetAddress(), 1)
+	if err := bc.ValidateTransaction(stolen); err == nil {
+		t.Fatal("spent a watch-only output with another key")
+	}
+	if _, err := bc.AddBlock([]*Transaction{stolen}); err == nil {
+		t.Fatal("mined a watch-only output spent with another key")
+	}
+
+	// 개인키를 가진 주인은 사용할 수 있음
+	if err := bc.ValidateTransaction(newTestTransaction(t, bc, owner, owner, other.GetAddress(), 1)); err != nil {
+		t.Fatal(err)
+	}
+}